@@ -0,0 +1,72 @@
+package markdown
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+var abbrDefRe = regexp.MustCompile(`^\*\[([^\]]+)\]:\s*(.*)$`)
+
+// collectAbbreviations removes abbreviation definitions ("*[API]: Application Programming Interface")
+// from lines and returns them.
+func collectAbbreviations(lines []string) ([]string, map[string]string) {
+	abbrs := make(map[string]string)
+	result := lines[:0:0]
+	fenced := false
+	for _, line := range lines {
+		if strings.HasPrefix(line, "```") {
+			fenced = !fenced
+		}
+		if m := abbrDefRe.FindStringSubmatch(line); m != nil && !fenced {
+			abbrs[m[1]] = strings.TrimSpace(m[2])
+			continue
+		}
+		result = append(result, line)
+	}
+	return result, abbrs
+}
+
+// abbrRegexp returns a regexp matching any of the abbreviations. Longer ones are preferred.
+func abbrRegexp(abbrs map[string]string) *regexp.Regexp {
+	if len(abbrs) == 0 {
+		return nil
+	}
+	words := make([]string, 0, len(abbrs))
+	for w := range abbrs {
+		words = append(words, regexp.QuoteMeta(w))
+	}
+	sort.Slice(words, func(i, j int) bool {
+		if len(words[i]) != len(words[j]) {
+			return len(words[i]) > len(words[j])
+		}
+		return words[i] < words[j]
+	})
+	return regexp.MustCompile(strings.Join(words, "|"))
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_'
+}
+
+// text writes a text node. Whole-word occurrences of abbreviations are written as Abbr.
+func (s *state) text(text string) {
+	if s.abbrRe == nil || s.inLink > 0 {
+		s.Write(text)
+		return
+	}
+	last := 0
+	for _, m := range s.abbrRe.FindAllStringIndex(text, -1) {
+		prev, _ := utf8.DecodeLastRuneInString(text[:m[0]])
+		next, _ := utf8.DecodeRuneInString(text[m[1]:])
+		if m[0] > 0 && isWordRune(prev) || m[1] < len(text) && isWordRune(next) {
+			continue
+		}
+		s.Write(text[last:m[0]])
+		s.Abbr(text[m[0]:m[1]], s.abbrs[text[m[0]:m[1]]])
+		last = m[1]
+	}
+	s.Write(text[last:])
+}
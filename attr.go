@@ -0,0 +1,83 @@
+package markdown
+
+import (
+	"regexp"
+	"strings"
+)
+
+var attrsSuffixRe = regexp.MustCompile(`\s*(\{[^{}]*\})\s*$`)
+
+// parseAttrs parses an attribute list ({#id .class key=value}) at the beginning of text.
+// It returns the attributes and the length of the list in text.
+func parseAttrs(text string) (Attrs, int, bool) {
+	if !strings.HasPrefix(text, "{") {
+		return nil, 0, false
+	}
+	var ids, classes []string
+	var pairs Attrs
+	pos := 1
+	for {
+		pos = skipSpaces(text, pos)
+		if pos >= len(text) {
+			return nil, 0, false
+		}
+		if text[pos] == '}' {
+			pos++
+			break
+		}
+		end := pos
+		for end < len(text) && text[end] != ' ' && text[end] != '\t' && text[end] != '}' && text[end] != '=' {
+			end++
+		}
+		name := text[pos:end]
+		pos = end
+		switch {
+		case strings.HasPrefix(name, "#") && len(name) > 1:
+			ids = append(ids, name[1:])
+		case strings.HasPrefix(name, ".") && len(name) > 1:
+			classes = append(classes, name[1:])
+		case name != "" && pos < len(text) && text[pos] == '=':
+			pos++
+			var value string
+			if pos < len(text) && (text[pos] == '"' || text[pos] == '\'') {
+				p := strings.IndexByte(text[pos+1:], text[pos])
+				if p < 0 {
+					return nil, 0, false
+				}
+				value = text[pos+1 : pos+1+p]
+				pos += p + 2
+			} else {
+				end := pos
+				for end < len(text) && text[end] != ' ' && text[end] != '\t' && text[end] != '}' {
+					end++
+				}
+				value = text[pos:end]
+				pos = end
+			}
+			pairs = append(pairs, Attr{name, unescape(value)})
+		default:
+			return nil, 0, false
+		}
+	}
+	var attrs Attrs
+	if len(ids) > 0 {
+		attrs = append(attrs, Attr{"id", ids[len(ids)-1]})
+	}
+	if len(classes) > 0 {
+		attrs = append(attrs, Attr{"class", strings.Join(classes, " ")})
+	}
+	return append(attrs, pairs...), pos, true
+}
+
+// trimAttrs removes a trailing attribute list from text and returns it.
+func trimAttrs(text string) (string, Attrs) {
+	m := attrsSuffixRe.FindStringSubmatchIndex(text)
+	if m == nil {
+		return text, nil
+	}
+	attrs, l, ok := parseAttrs(text[m[2]:m[3]])
+	if !ok || l != m[3]-m[2] {
+		return text, nil
+	}
+	return text[:m[0]], attrs
+}
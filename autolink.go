@@ -0,0 +1,81 @@
+package markdown
+
+import (
+	"regexp"
+	"strings"
+)
+
+// PrecedingMatcher is implemented by matchers which depend on the preceding character.
+type PrecedingMatcher interface {
+	Matcher
+	CanFollow(prev rune) bool
+}
+
+// AutolinkMatcher matches bare URLs and e-mail addresses in text.
+type AutolinkMatcher struct {
+	PrefixStr string
+	Re        *regexp.Regexp
+	Scheme    string // prepended to the matched text to build the URL
+}
+
+func (m *AutolinkMatcher) Prefix() string {
+	return m.PrefixStr
+}
+
+func (m *AutolinkMatcher) CanFollow(prev rune) bool {
+	return isSpaceRune(prev) || strings.ContainsRune("*_~(", prev)
+}
+
+func (m *AutolinkMatcher) TryMatch(text string) (int, []string) {
+	match := m.Re.FindString(text)
+	if match == "" {
+		return -1, nil
+	}
+	match = trimAutolink(match)
+	if m.Scheme == "mailto:" && strings.ContainsAny(match[len(match)-1:], "-_") {
+		return -1, nil
+	}
+	return len(match), []string{match}
+}
+
+func (m *AutolinkMatcher) Render(params []string, md *state) {
+	n := md.Link(m.Scheme+params[0], "", 0)
+	md.Write(params[0])
+	md.End(n)
+}
+
+// trimAutolink removes trailing punctuation and unbalanced parentheses from an URL.
+func trimAutolink(url string) string {
+	for len(url) > 0 {
+		switch url[len(url)-1] {
+		case '?', '!', '.', ',', ':', '*', '_', '~', '\'', '"':
+			url = url[:len(url)-1]
+		case ')':
+			if strings.Count(url, "(") >= strings.Count(url, ")") {
+				return url
+			}
+			url = url[:len(url)-1]
+		case ';':
+			p := strings.LastIndex(url, "&")
+			if p < 0 || !entityRe.MatchString(url[p:]) {
+				return url
+			}
+			url = url[:p]
+		default:
+			return url
+		}
+	}
+	return url
+}
+
+func autolink(params []string, md *state, markup *RegexMatcher) {
+	n := md.Link(params[1], "", 0)
+	md.Write(params[1])
+	md.End(n)
+}
+
+func emailAutolink(params []string, md *state, markup *RegexMatcher) {
+	n := md.Link("mailto:"+params[1], "", 0)
+	md.Write(params[1])
+	md.End(n)
+}
@@ -0,0 +1,141 @@
+package markdown
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// DelimiterMatcher matches a run of emphasis delimiters ("*" or "_").
+// Runs are paired by the delimiter stack algorithm of CommonMark and rendered
+// by Single (one delimiter) or Double (two delimiters).
+type DelimiterMatcher struct {
+	Delim  byte
+	Single func(s *state) int
+	Double func(s *state) int
+}
+
+func (m *DelimiterMatcher) Prefix() string {
+	return string(m.Delim)
+}
+
+func (m *DelimiterMatcher) TryMatch(line string) (int, []string) {
+	n := 1
+	for n < len(line) && line[n] == m.Delim {
+		n++
+	}
+	return n, []string{line[:n]}
+}
+
+func (m *DelimiterMatcher) Render(params []string, s *state) {
+	// unpaired delimiters.
+	s.Write(params[0])
+}
+
+// delimiter is an entry of the delimiter stack.
+type delimiter struct {
+	matcher  *DelimiterMatcher
+	length   int // original length of the run
+	count    int // remaining delimiters
+	canOpen  bool
+	canClose bool
+	opens    []*emphasisPair
+	closes   []*emphasisPair
+}
+
+type emphasisPair struct {
+	render func(s *state) int
+	depth  int
+}
+
+func isSpaceRune(r rune) bool {
+	return unicode.IsSpace(r)
+}
+
+func isPunctRune(r rune) bool {
+	if r < utf8.RuneSelf {
+		return isASCIIPunct(byte(r))
+	}
+	return unicode.IsPunct(r) || unicode.IsSymbol(r)
+}
+
+func isASCIIPunct(c byte) bool {
+	return c >= '!' && c <= '/' || c >= ':' && c <= '@' || c >= '[' && c <= '`' || c >= '{' && c <= '~'
+}
+
+// newDelimiter returns a delimiter run with flanking flags computed from the
+// characters before and after the run. If cjk is true, CJK characters next to
+// the run are treated like punctuation.
+func newDelimiter(m *DelimiterMatcher, length int, before, after string, cjk bool) *delimiter {
+	prev, next := ' ', ' '
+	if before != "" {
+		prev, _ = utf8.DecodeLastRuneInString(before)
+	}
+	if after != "" {
+		next, _ = utf8.DecodeRuneInString(after)
+	}
+	prevPunct := isPunctRune(prev) || cjk && isCJK(prev)
+	nextPunct := isPunctRune(next) || cjk && isCJK(next)
+	left := !isSpaceRune(next) && (!isPunctRune(next) || isSpaceRune(prev) || prevPunct)
+	right := !isSpaceRune(prev) && (!isPunctRune(prev) || isSpaceRune(next) || nextPunct)
+
+	d := &delimiter{matcher: m, length: length, count: length}
+	if m.Delim == '_' {
+		d.canOpen = left && (!right || prevPunct)
+		d.canClose = right && (!left || nextPunct)
+	} else {
+		d.canOpen = left
+		d.canClose = right
+	}
+	return d
+}
+
+// processEmphasis pairs openers and closers in the delimiter stack.
+func processEmphasis(delims []*delimiter) {
+	for ci := 0; ci < len(delims); ci++ {
+		closer := delims[ci]
+		if !closer.canClose || closer.count == 0 {
+			continue
+		}
+		for oi := ci - 1; oi >= 0; oi-- {
+			opener := delims[oi]
+			if opener.matcher != closer.matcher || !opener.canOpen || opener.count == 0 {
+				continue
+			}
+			if (opener.canClose || closer.canOpen) && (opener.length+closer.length)%3 == 0 &&
+				!(opener.length%3 == 0 && closer.length%3 == 0) {
+				continue
+			}
+			n := 1
+			pair := &emphasisPair{render: opener.matcher.Single}
+			if opener.count >= 2 && closer.count >= 2 {
+				n = 2
+				pair.render = opener.matcher.Double
+			}
+			opener.count -= n
+			closer.count -= n
+			opener.opens = append([]*emphasisPair{pair}, opener.opens...)
+			closer.closes = append(closer.closes, pair)
+			for _, d := range delims[oi+1 : ci] {
+				d.canOpen = false
+				d.canClose = false
+			}
+			if closer.count > 0 {
+				ci--
+			}
+			break
+		}
+	}
+}
+
+func (d *delimiter) render(s *state) {
+	for _, p := range d.closes {
+		s.End(p.depth)
+	}
+	if d.count > 0 {
+		s.Write(strings.Repeat(d.matcher.Prefix(), d.count))
+	}
+	for _, p := range d.opens {
+		p.depth = p.render(s)
+	}
+}
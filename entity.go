@@ -0,0 +1,35 @@
+package markdown
+
+import (
+	"html"
+	"regexp"
+	"strings"
+)
+
+var entityRe = regexp.MustCompile(`^&(?:#[xX][0-9a-fA-F]{1,6}|#[0-9]{1,7}|[A-Za-z][A-Za-z0-9]{1,31});`)
+
+// unescape decodes backslash escapes and entity references in text.
+func unescape(text string) string {
+	if !strings.ContainsAny(text, "\\&") {
+		return text
+	}
+	var buf strings.Builder
+	for pos := 0; pos < len(text); pos++ {
+		c := text[pos]
+		if c == '\\' && pos+1 < len(text) && isASCIIPunct(text[pos+1]) {
+			pos++
+			buf.WriteByte(text[pos])
+		} else if c == '&' && entityRe.MatchString(text[pos:]) {
+			ent := entityRe.FindString(text[pos:])
+			buf.WriteString(html.UnescapeString(ent))
+			pos += len(ent) - 1
+		} else {
+			buf.WriteByte(c)
+		}
+	}
+	return buf.String()
+}
+
+func entity(params []string, md *state, markup *RegexMatcher) {
+	md.Write(html.UnescapeString(params[0]))
+}
@@ -0,0 +1,180 @@
+package markdown
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// scanLinkText returns the position of the bracket closing the link text, or -1.
+// The text must not contain the opening bracket.
+func scanLinkText(text string) int {
+	depth := 0
+	for pos := 0; pos < len(text); pos++ {
+		switch text[pos] {
+		case '\\':
+			pos++
+		case '`':
+			n := 1
+			for pos+n < len(text) && text[pos+n] == '`' {
+				n++
+			}
+			run := text[pos : pos+n]
+			if p := strings.Index(text[pos+n:], run); p >= 0 {
+				pos += n + p + n - 1
+			} else {
+				pos += n - 1
+			}
+		case '[':
+			depth++
+		case ']':
+			if depth == 0 {
+				return pos
+			}
+			depth--
+		}
+	}
+	return -1
+}
+
+// parseLinkDestination parses a link destination at the beginning of text.
+// It returns the raw destination and its length in text.
+func parseLinkDestination(text string) (string, int, bool) {
+	if strings.HasPrefix(text, "<") {
+		for pos := 1; pos < len(text); pos++ {
+			switch text[pos] {
+			case '\\':
+				pos++
+			case '<', '\n':
+				return "", 0, false
+			case '>':
+				return text[1:pos], pos + 1, true
+			}
+		}
+		return "", 0, false
+	}
+	depth := 0
+	pos := 0
+loop:
+	for ; pos < len(text); pos++ {
+		c := text[pos]
+		switch {
+		case c == '\\' && pos+1 < len(text) && isASCIIPunct(text[pos+1]):
+			pos++
+		case c == '(':
+			depth++
+		case c == ')':
+			if depth == 0 {
+				break loop
+			}
+			depth--
+		case c <= ' ' || c == 0x7f:
+			break loop
+		}
+	}
+	if depth != 0 {
+		return "", 0, false
+	}
+	return text[:pos], pos, true
+}
+
+// parseLinkTitle parses a link title in double quotes, single quotes or
+// parentheses at the beginning of text.
+// It returns the raw title and its length in text.
+func parseLinkTitle(text string) (string, int, bool) {
+	if text == "" {
+		return "", 0, false
+	}
+	closer := map[byte]byte{'"': '"', '\'': '\'', '(': ')'}[text[0]]
+	if closer == 0 {
+		return "", 0, false
+	}
+	for pos := 1; pos < len(text); pos++ {
+		switch c := text[pos]; {
+		case c == '\\':
+			pos++
+		case c == closer:
+			return text[1:pos], pos + 1, true
+		case c == '(' && closer == ')':
+			return "", 0, false
+		}
+	}
+	return "", 0, false
+}
+
+func skipSpaces(text string, pos int) int {
+	for pos < len(text) && (text[pos] == ' ' || text[pos] == '\t') {
+		pos++
+	}
+	return pos
+}
+
+// parseInlineLink parses "(destination "title")" at the beginning of text.
+// It returns the raw destination, title and the length of the link in text.
+func parseInlineLink(text string) (string, string, int, bool) {
+	if !strings.HasPrefix(text, "(") {
+		return "", "", 0, false
+	}
+	pos := skipSpaces(text, 1)
+	dest, n, ok := parseLinkDestination(text[pos:])
+	if !ok {
+		return "", "", 0, false
+	}
+	pos += n
+	p := skipSpaces(text, pos)
+	var title string
+	if p > pos && p < len(text) && text[p] != ')' {
+		title, n, ok = parseLinkTitle(text[p:])
+		if !ok {
+			return "", "", 0, false
+		}
+		p = skipSpaces(text, p+n)
+	}
+	if p >= len(text) || text[p] != ')' {
+		return "", "", 0, false
+	}
+	return dest, title, p + 1, true
+}
+
+// WikiLinkResolver maps a page name to the URL and reports whether the page exists.
+type WikiLinkResolver func(page string) (url string, exists bool)
+
+var wikiLinkRe = regexp.MustCompile(`^\[\[([^\[\]|]+)(?:\|([^\[\]]+))?\]\]`)
+
+// WikiLinkMatcher matches [[Page Name]] and [[Page Name|label]].
+type WikiLinkMatcher struct {
+	Resolver WikiLinkResolver
+}
+
+func (m *WikiLinkMatcher) Prefix() string {
+	return "[["
+}
+
+func (m *WikiLinkMatcher) TryMatch(line string) (int, []string) {
+	match := wikiLinkRe.FindStringSubmatch(line)
+	if len(match) < 1 || strings.TrimSpace(match[1]) == "" {
+		return -1, nil
+	}
+	return len(match[0]), match
+}
+
+func (m *WikiLinkMatcher) Render(params []string, md *state) {
+	page := strings.TrimSpace(params[1])
+	label := params[2]
+	if label == "" {
+		label = page
+	}
+	href, exists := url.PathEscape(page), true
+	if m.Resolver != nil {
+		href, exists = m.Resolver(page)
+	}
+	opt := LinkWiki
+	if !exists {
+		opt |= LinkMissing
+	}
+	n := md.Link(href, "", opt)
+	md.inLink++
+	md.inline(label)
+	md.inLink--
+	md.End(n)
+}
@@ -6,6 +6,7 @@ import (
 	"io"
 	"regexp"
 	"strings"
+	"unicode/utf8"
 )
 
 type Matcher interface {
@@ -68,39 +69,38 @@ func (m *LinkInlineMatcher) Prefix() string {
 }
 
 func (m *LinkInlineMatcher) TryMatch(line string) (int, []string) {
-	p1 := strings.Index(line, "](")
-	if p1 < 0 {
+	p := scanLinkText(line[len(m.Start):])
+	if p < 0 {
 		return -1, nil
 	}
-
-	pos := len(m.Start)
-retry:
-	if strings.Index(line[pos:p1], "![") >= 0 {
-		if p := strings.Index(line[p1+1:], "]("); p >= 0 {
-			pos = p1
-			p1 += p + 1
-			goto retry
-		}
-	}
-	p2 := strings.Index(line[p1:], ")") + p1
-	if p2 < 0 {
+	p += len(m.Start)
+	url, title, l, ok := parseInlineLink(line[p+1:])
+	if !ok {
 		return -1, nil
 	}
-	return p2 + 1, []string{line[len(m.Start):p1], line[p1+2 : p2]}
+	end := p + 1 + l
+	attrs := ""
+	if _, n, ok := parseAttrs(line[end:]); ok {
+		attrs = line[end : end+n]
+		end += n
+	}
+	return end, []string{line[len(m.Start):p], url, title, attrs}
 }
 
 func (m *LinkInlineMatcher) Render(params []string, md *state) {
-	url := strings.Split(params[1], " ")
-	var title string
-	if len(url) > 1 {
-		title = strings.Trim(url[1], "\" ")
+	url := unescape(params[1])
+	title := unescape(params[2])
+	if attrs, _, ok := parseAttrs(params[3]); ok {
+		md.Attributes(attrs)
 	}
 	if m.Start == "![" {
-		n := md.Image(url[0], title, params[0], 0)
+		n := md.Image(url, title, params[0], 0)
 		md.End(n)
 	} else {
-		n := md.Link(url[0], title, 0)
+		n := md.Link(url, title, 0)
+		md.inLink++
 		md.inline(params[0])
+		md.inLink--
 		md.End(n)
 	}
 }
@@ -111,32 +111,54 @@ func strike(text string, md *state, markup *SimpleInlineMatcher) {
 	md.End(n)
 }
 
-func strong(text string, md *state, markup *SimpleInlineMatcher) {
-	n := md.Strong()
+func mark(text string, md *state, markup *SimpleInlineMatcher) {
+	n := md.Mark()
 	md.inline(text)
 	md.End(n)
 }
 
-func emphasis(text string, md *state, markup *SimpleInlineMatcher) {
-	n := md.Emphasis()
+func insert(text string, md *state, markup *SimpleInlineMatcher) {
+	n := md.Insert()
 	md.inline(text)
 	md.End(n)
 }
 
-func icode(text string, md *state, markup *SimpleInlineMatcher) {
-	n := md.Code()
-	md.Write(text)
+func superscript(params []string, md *state, markup *RegexMatcher) {
+	n := md.Superscript()
+	md.inline(params[1])
 	md.End(n)
 }
 
-func autolink(params []string, md *state, markup *RegexMatcher) {
-	n := md.Link(params[0], "", 0)
-	md.Write(params[0])
+func subscript(params []string, md *state, markup *RegexMatcher) {
+	n := md.Subscript()
+	md.inline(params[1])
+	md.End(n)
+}
+
+func ruby(params []string, md *state, markup *RegexMatcher) {
+	md.Ruby(unescape(params[1]), unescape(params[2]))
+}
+
+func strong(md *state) int {
+	return md.Strong()
+}
+
+func emphasis(md *state) int {
+	return md.Emphasis()
+}
+
+func icode(text string, md *state, markup *SimpleInlineMatcher) {
+	n := md.Code()
+	md.Write(strings.Replace(text, "\n", " ", -1))
 	md.End(n)
 }
 
 func heading(params []string, md *state, markup *RegexMatcher) {
-	md.Heading(params[2], len(params[1]))
+	text, attrs := trimAttrs(params[2])
+	if attrs != nil {
+		md.Attributes(attrs)
+	}
+	md.Heading(text, len(params[1]))
 }
 
 func hr(params []string, md *state, markup *RegexMatcher) {
@@ -185,7 +207,10 @@ func list(params []string, s *state, markup *RegexMatcher) {
 
 func code(params []string, s *state, markup *RegexMatcher) {
 	lang := params[1]
-	n := s.CodeBlock(lang, params[2])
+	if attrs, _, ok := parseAttrs(params[3]); ok {
+		s.Attributes(attrs)
+	}
+	n := s.CodeBlock(lang, strings.TrimSpace(params[2]))
 	defer s.End(n)
 
 	tokenizer := NewTokenizer(lang)
@@ -208,58 +233,11 @@ func code(params []string, s *state, markup *RegexMatcher) {
 	}
 }
 
-func table(params []string, md *state, markup *RegexMatcher) {
-	align := make(map[int]int, len(params))
-	if md.Scan() {
-		t := markup.Re.FindStringSubmatch(md.Text())
-		if len(t) > 0 {
-			for i, s := range strings.Split(t[1], "|") {
-				if strings.Trim(s, " -:|") != "" {
-					md.Retry()
-					break
-				}
-				if strings.HasPrefix(s, ":") {
-					align[i] |= 1
-				}
-				if strings.HasSuffix(s, ":") {
-					align[i] |= 2
-				}
-			}
-		} else {
-			md.Retry()
-		}
-	}
-	nt := md.Table()
-	h := 4
-	for {
-		text := params[1]
-		nr := md.TableRow()
-		for i, s := range strings.Split(text, "|") {
-			nc := md.TableCell(align[i] | h)
-			md.inline(s)
-			md.End(nc)
-		}
-		md.End(nr)
-		h = 0
-
-		if !md.Scan() {
-			break
-		}
-		text = md.Text()
-		params = markup.Re.FindStringSubmatch(text)
-		if len(params) < 1 {
-			md.Retry()
-			break
-		}
-	}
-	md.End(nt)
-}
-
 func quote(params []string, md *state, markup *RegexMatcher) {
 	n := md.QuoteBlock()
 	defer md.End(n)
 
-	md.inline(params[1] + "\n")
+	lines := []string{params[1]}
 	for md.Scan() {
 		text := md.Text()
 		if text == "" {
@@ -269,8 +247,10 @@ func quote(params []string, md *state, markup *RegexMatcher) {
 		if len(params) > 0 {
 			text = params[1]
 		}
-		md.inline(text + "\n")
+		lines = append(lines, text)
 	}
+	md.inlineLines(lines)
+	md.Write("\n")
 }
 
 func pluginBlock(params []string, md *state, markup *RegexMatcher) {
@@ -289,20 +269,24 @@ var defaultBlockElems []Matcher
 func init() {
 	defaultInlineElems = []Matcher{
 		&SimpleInlineMatcher{"~~", "~~", strike},
-		&SimpleInlineMatcher{"**", "**", strong},
-		&SimpleInlineMatcher{"*", "*", emphasis},
+		&DelimiterMatcher{'*', emphasis, strong},
+		&DelimiterMatcher{'_', emphasis, strong},
 		&SimpleInlineMatcher{"``", "``", icode},
 		&SimpleInlineMatcher{"`", "`", icode},
-		&SimpleInlineMatcher{"__", "__", strong},
 		&LinkInlineMatcher{"["},
 		&LinkInlineMatcher{"!["},
-		&RegexMatcher{"http", regexp.MustCompile(`^https?:[^\s\"\'\)<>]+`), autolink},
+		&RegexMatcher{"<", regexp.MustCompile(`^<([A-Za-z][A-Za-z0-9+.-]{1,31}:[^\s<>]*)>`), autolink},
+		&RegexMatcher{"<", regexp.MustCompile(`^<([A-Za-z0-9.!#$%&'*+/=?^_{|}~-]+@[A-Za-z0-9](?:[A-Za-z0-9-]{0,61}[A-Za-z0-9])?(?:\.[A-Za-z0-9](?:[A-Za-z0-9-]{0,61}[A-Za-z0-9])?)*)>`), emailAutolink},
+		&AutolinkMatcher{"http", regexp.MustCompile(`^https?://[^\s<>]+`), ""},
+		&AutolinkMatcher{"www.", regexp.MustCompile(`^www\.[A-Za-z0-9_-]+(?:\.[A-Za-z0-9_-]+)*[^\s<>]*`), "http://"},
+		&AutolinkMatcher{"", regexp.MustCompile(`^[A-Za-z0-9._+-]+@[A-Za-z0-9_-]+(?:\.[A-Za-z0-9_-]+)+`), "mailto:"},
+		&RegexMatcher{"&", entityRe, entity},
 	}
 	defaultBlockElems = []Matcher{
-		&RegexMatcher{"#", regexp.MustCompile(`^(#{1,4})\s*(.*)`), heading},
+		&RegexMatcher{"#", regexp.MustCompile(`^(#{1,4})(?:\s+(.*)|$)`), heading},
 		&RegexMatcher{">", regexp.MustCompile(`^>+\s?(.*)`), quote},
-		&RegexMatcher{"```", regexp.MustCompile("^```\\s*(\\w*)(:.*)?$"), code},
-		&RegexMatcher{"", regexp.MustCompile(`^\|(.+)\|$`), table},
+		&RegexMatcher{"```", regexp.MustCompile("^```\\s*(\\w*)(:[^{]*)?\\s*(\\{[^{}]*\\})?\\s*$"), code},
+		&TableMatcher{},
 		&RegexMatcher{"", regexp.MustCompile(`^(\s*)(-|\*|\+|\d+\.)\s(.+)$`), list},
 		&RegexMatcher{"", regexp.MustCompile(`^([-_]\s?){3,}$`), hr},
 		&RegexMatcher{"[", regexp.MustCompile(`^\[([^\]]+)\]:\s+([^\s]+)\s+(.*)`), dummy}, // fixme
@@ -310,39 +294,81 @@ func init() {
 	}
 }
 
+// Optional inline matchers. Use Markdown.AddInline to enable them.
+var (
+	MarkMatcher        Matcher = &SimpleInlineMatcher{"==", "==", mark}
+	InsertMatcher      Matcher = &SimpleInlineMatcher{"++", "++", insert}
+	SuperscriptMatcher Matcher = &RegexMatcher{"^", regexp.MustCompile(`^\^((?:\\.|[^\s\\^])+)\^`), superscript}
+	SubscriptMatcher   Matcher = &RegexMatcher{"~", regexp.MustCompile(`^~((?:\\.|[^\s\\~])+)~`), subscript}
+	RubyMatcher        Matcher = &RegexMatcher{"{", regexp.MustCompile(`^\{([^{}|]+)\|([^{}|]+)\}`), ruby}
+	AozoraRubyMatcher  Matcher = &RegexMatcher{"｜", regexp.MustCompile(`^｜([^｜《》]+)《([^《》]+)》`), ruby}
+)
+
 // Markdown config.
 type Markdown struct {
 	inlineElems   []Matcher
 	blockElems    []Matcher
 	inlineCharMap map[byte]bool
+
+	// Autolink enables links for bare URLs, www. and e-mail addresses.
+	Autolink bool
+	// CJK drops line breaks between CJK characters and allows emphasis next to CJK text.
+	CJK bool
 }
 
 // NewMarkdown returns *Markdown
 func NewMarkdown() *Markdown {
-	m := make(map[byte]bool)
-	for _, markup := range defaultInlineElems {
-		m[markup.Prefix()[0]] = true
+	md := &Markdown{blockElems: defaultBlockElems, inlineCharMap: make(map[byte]bool), Autolink: true}
+	md.AddInline(defaultInlineElems...)
+	return md
+}
+
+// AddInline adds inline matchers. Added matchers are tried after the existing ones.
+func (md *Markdown) AddInline(matchers ...Matcher) {
+	md.inlineElems = append(md.inlineElems[:len(md.inlineElems):len(md.inlineElems)], matchers...)
+	for _, markup := range matchers {
+		if p := markup.Prefix(); p != "" {
+			md.inlineCharMap[p[0]] = true
+		} else {
+			for c := 0; c < 256; c++ {
+				md.inlineCharMap[byte(c)] = true
+			}
+		}
 	}
-	return &Markdown{defaultInlineElems, defaultBlockElems, m}
 }
 
 type state struct {
 	*Markdown
-	*bufio.Scanner
 	DocWriter
-	retry bool
+	lines  []string
+	pos    int
+	inLink int
+	abbrs  map[string]string
+	abbrRe *regexp.Regexp
 }
 
 func (s *state) Scan() bool {
-	if s.retry {
-		s.retry = false
-		return true
+	if s.pos >= len(s.lines) {
+		return false
 	}
-	return s.Scanner.Scan()
+	s.pos++
+	return true
+}
+
+func (s *state) Text() string {
+	return s.lines[s.pos-1]
 }
 
 func (s *state) Retry() {
-	s.retry = true
+	s.pos--
+}
+
+// Peek returns the next line without consuming it.
+func (s *state) Peek() (string, bool) {
+	if s.pos >= len(s.lines) {
+		return "", false
+	}
+	return s.lines[s.pos], true
 }
 
 type LimitedReader struct {
@@ -356,7 +382,7 @@ func (r *LimitedReader) Read(p []byte) (n int, err error) {
 		if !r.scanner.Scan() {
 			return 0, io.EOF
 		}
-		r.buf = r.scanner.Bytes()
+		r.buf = []byte(r.scanner.Text())
 		if bytes.HasPrefix(r.buf, r.delimiter) {
 			return 0, io.EOF
 		}
@@ -367,47 +393,138 @@ func (r *LimitedReader) Read(p []byte) (n int, err error) {
 	return l, nil
 }
 
+// inlineNode is a text, a matched markup, a delimiter run or a line break in an inline text.
+type inlineNode struct {
+	text   string
+	markup Matcher
+	params []string
+	delim  *delimiter
+	brk    bool
+	hard   bool
+}
+
 func (s *state) inline(text string) {
-	writer := s.DocWriter
-	for pos := 0; pos < len(text); pos++ {
-		// TODO more fast.
-		if s.inlineCharMap[text[pos]] || true {
-			if pos > 0 && text[pos-1] == '\\' {
-				// Escaped
-				writer.Write(text[:pos-1])
-				text = text[pos:]
-				pos = 1
+	var nodes []inlineNode
+	var delims []*delimiter
+	start := 0
+	for pos := 0; pos < len(text); {
+		if text[pos] == '\n' || text[pos] == '\\' && pos+1 < len(text) && text[pos+1] == '\n' {
+			// Line break. Two or more spaces or a backslash before it make a hard break.
+			line := text[start:pos]
+			hard := text[pos] == '\\' || len(line)-len(strings.TrimRight(line, " ")) >= 2
+			nodes = append(nodes, inlineNode{text: strings.TrimRight(line, " ")})
+			if text[pos] == '\\' {
+				pos++
+			}
+			pos++
+			start = pos
+			if !hard && s.CJK {
+				last, _ := utf8.DecodeLastRuneInString(strings.TrimRight(text[:pos-1], " "))
+				next, _ := utf8.DecodeRuneInString(strings.TrimLeft(text[pos:], " "))
+				if isCJK(last) && isCJK(next) {
+					continue
+				}
+			}
+			nodes = append(nodes, inlineNode{brk: true, hard: hard})
+			continue
+		}
+		if text[pos] == '\\' && pos+1 < len(text) && isASCIIPunct(text[pos+1]) {
+			// Escaped
+			nodes = append(nodes, inlineNode{text: text[start:pos]})
+			start = pos + 1
+			pos += 2
+			continue
+		}
+		if !s.inlineCharMap[text[pos]] {
+			pos++
+			continue
+		}
+		l := 0
+		for _, markup := range s.inlineElems {
+			if !strings.HasPrefix(text[pos:], markup.Prefix()) {
+				continue
+			}
+			if _, ok := markup.(*AutolinkMatcher); ok && (!s.Autolink || s.inLink > 0) {
 				continue
 			}
-			for _, markup := range s.inlineElems {
-				if strings.HasPrefix(text[pos:], markup.Prefix()) {
-					l, params := markup.TryMatch(text[pos:])
-					if l > 0 {
-						writer.Write(text[:pos])
-						markup.Render(params, s)
-						text = text[(pos + l):]
-						pos = 0
-					}
+			if m, ok := markup.(PrecedingMatcher); ok {
+				prev := ' '
+				if pos > 0 {
+					prev, _ = utf8.DecodeLastRuneInString(text[:pos])
 				}
-
+				if !m.CanFollow(prev) {
+					continue
+				}
+			}
+			var params []string
+			l, params = markup.TryMatch(text[pos:])
+			if l <= 0 {
+				continue
+			}
+			nodes = append(nodes, inlineNode{text: text[start:pos]})
+			if m, ok := markup.(*DelimiterMatcher); ok {
+				d := newDelimiter(m, l, text[:pos], text[pos+l:], s.CJK)
+				delims = append(delims, d)
+				nodes = append(nodes, inlineNode{delim: d})
+			} else {
+				nodes = append(nodes, inlineNode{markup: markup, params: params})
 			}
+			break
+		}
+		if l > 0 {
+			pos += l
+			start = pos
+		} else {
+			pos++
 		}
 	}
-	writer.Write(text)
+	nodes = append(nodes, inlineNode{text: text[start:]})
+
+	processEmphasis(delims)
+	for _, n := range nodes {
+		if n.delim != nil {
+			n.delim.render(s)
+		} else if n.markup != nil {
+			n.markup.Render(n.params, s)
+		} else if n.brk {
+			s.LineBreak(n.hard)
+		} else if n.text != "" {
+			s.text(n.text)
+		}
+	}
+}
+
+// inlineLines renders lines joined with line breaks.
+// The lines are parsed at once so that inline markup can span lines.
+func (s *state) inlineLines(lines []string) {
+	s.inline(strings.TrimRight(strings.Join(lines, "\n"), " "))
+}
+
+func (s *state) paragraph(lines []string) {
+	if len(lines) > 0 {
+		n := s.Paragraph()
+		s.inlineLines(lines)
+		s.End(n)
+	}
 }
 
 func (s *state) block() {
 	writer := s.DocWriter
-	para := 0
+	var para []string
 	for s.Scan() {
 		text := s.Text()
 		for _, matcher := range s.blockElems {
-			l, params := matcher.TryMatch(text)
+			var l int
+			var params []string
+			if m, ok := matcher.(LookaheadMatcher); ok {
+				next, _ := s.Peek()
+				l, params = m.TryMatchNext(text, next)
+			} else {
+				l, params = matcher.TryMatch(text)
+			}
 			if l > 0 {
-				if para != 0 {
-					writer.End(para)
-					para = 0
-				}
+				s.paragraph(para)
+				para = nil
 				writer.Write("\n")
 				matcher.Render(params, s)
 				text = ""
@@ -415,25 +532,56 @@ func (s *state) block() {
 			}
 		}
 		if text == "" {
-			if para != 0 {
-				writer.End(para)
-				para = 0
-			}
+			s.paragraph(para)
+			para = nil
 			continue
 		}
-		if para == 0 {
-			para = writer.Paragraph()
-		} else {
-			s.Write("\n")
+		para = append(para, text)
+	}
+	s.paragraph(para)
+}
+
+var frontMatterRe = regexp.MustCompile(`^(\w+):\s*(.*)$`)
+
+// splitFrontMatter removes front matter ("---", "key: value" lines and "---") at the first line
+// and returns its key-value pairs, or nil if lines do not start with front matter.
+func splitFrontMatter(lines []string) ([]string, map[string]string) {
+	if len(lines) == 0 || lines[0] != "---" {
+		return lines, nil
+	}
+	front := make(map[string]string)
+	for i, line := range lines[1:] {
+		if line == "---" {
+			return lines[i+2:], front
 		}
-		s.inline(text)
+		m := frontMatterRe.FindStringSubmatch(line)
+		if m == nil {
+			return lines, nil
+		}
+		front[strings.ToLower(m[1])] = strings.Trim(strings.TrimSpace(m[2]), `"'`)
 	}
+	return lines, nil
 }
 
 // Convert md to html.
-func Convert(scanner0 *bufio.Scanner, writer DocWriter) error {
-	state := &state{NewMarkdown(), scanner0, writer, false}
+func (md *Markdown) Convert(scanner0 *bufio.Scanner, writer DocWriter) error {
+	state := &state{Markdown: md, DocWriter: writer}
+	for scanner0.Scan() {
+		state.lines = append(state.lines, scanner0.Text())
+	}
+	if w, ok := writer.(FrontMatterWriter); ok {
+		var front map[string]string
+		if state.lines, front = splitFrontMatter(state.lines); front != nil {
+			w.FrontMatter(front)
+		}
+	}
+	state.lines, state.abbrs = collectAbbreviations(state.lines)
+	state.abbrRe = abbrRegexp(state.abbrs)
 	state.block()
-	// NewMarkdown().block(&Source{scanner: scanner0}, writer)
 	return scanner0.Err()
 }
+
+// Convert md to html.
+func Convert(scanner0 *bufio.Scanner, writer DocWriter) error {
+	return NewMarkdown().Convert(scanner0, writer)
+}
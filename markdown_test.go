@@ -14,63 +14,139 @@ type expect struct {
 	expected string
 }
 
+var convertTests = []expect{
+	// inline
+	expect{"hello\nworld", "<p>hello\nworld</p>"},
+	expect{"hello\n\nworld", "<p>hello</p>\n<p>world</p>"},
+	expect{"hello  \nworld", "<p>hello<br/>\nworld</p>"},
+	expect{"hello\\\nworld", "<p>hello<br/>\nworld</p>"},
+	expect{"hello\\\\\nworld", "<p>hello\\\nworld</p>"},
+	expect{"hello  ", "<p>hello</p>"},
+	expect{"a *b\nc* d", "<p>a <em>b\nc</em> d</p>"},
+	expect{"**a\nb**", "<p><strong>a\nb</strong></p>"},
+	expect{"`a\nb`", "<p><code>a b</code></p>"},
+	expect{"[a\nb](c)", "<p><a href='c'>a\nb</a></p>"},
+	expect{"*a  \nb*", "<p><em>a<br/>\nb</em></p>"},
+	expect{`~~hello~~`, `<p><strike>hello</strike></p>`},
+	expect{`**hello**`, `<p><strong>hello</strong></p>`},
+	expect{`*hello*`, `<p><em>hello</em></p>`},
+	expect{`~~**hello**~~`, `<p><strike><strong>hello</strong></strike></p>`},
+	expect{"`this is code.`", `<p><code>this is code.</code></p>`},
+	expect{"``this is `code`.``", "<p><code>this is `code`.</code></p>"},
+	expect{`\*escaped*`, `<p>*escaped*</p>`},
+	expect{`\\\[\]\#\_\~\&amp;`, `<p>\[]#_~&amp;amp;</p>`},
+	expect{`a\b\1\ c\`, `<p>a\b\1\ c\</p>`},
+	expect{"`\\*`", `<p><code>\*</code></p>`},
+	expect{`&copy; &#35; &#x1F600; &amp;`, `<p>© # 😀 &amp;</p>`},
+	expect{`&nosuch; &copy`, `<p>&amp;nosuch; &amp;copy</p>`},
+	expect{`aaa ** bbb`, `<p>aaa ** bbb</p>`},
+	expect{`2 * 3 * 4`, `<p>2 * 3 * 4</p>`},
+	expect{`snake_case_names`, `<p>snake_case_names</p>`},
+	expect{`_hello_`, `<p><em>hello</em></p>`},
+	expect{`__hello__`, `<p><strong>hello</strong></p>`},
+	expect{`***both***`, `<p><em><strong>both</strong></em></p>`},
+	expect{`*foo**bar**baz*`, `<p><em>foo<strong>bar</strong>baz</em></p>`},
+	expect{`foo*bar*`, `<p>foo<em>bar</em></p>`},
+	expect{`foo_bar_`, `<p>foo_bar_</p>`},
+	expect{`**foo*`, `<p>*<em>foo</em></p>`},
+	expect{`*foo _bar* baz_`, `<p><em>foo _bar</em> baz_</p>`},
+	expect{"url: http://www.example.com/?hello", "<p>url: <a href='http://www.example.com/?hello'>http://www.example.com/?hello</a></p>"},
+	expect{"see http://example.com/a_(b).", "<p>see <a href='http://example.com/a_(b)'>http://example.com/a_(b)</a>.</p>"},
+	expect{"(http://example.com/a), xhttp://example.com", "<p>(<a href='http://example.com/a'>http://example.com/a</a>), xhttp://example.com</p>"},
+	expect{"visit www.example.com/?q=1&amp;", "<p>visit <a href='http://www.example.com/?q=1'>www.example.com/?q=1</a>&amp;</p>"},
+	expect{"mail: foo.bar@example.com.", "<p>mail: <a href='mailto:foo.bar@example.com'>foo.bar@example.com</a>.</p>"},
+	expect{"<https://example.com/a b> <https://example.com/?a=b>", "<p>&lt;https://example.com/a b&gt; <a href='https://example.com/?a=b'>https://example.com/?a=b</a></p>"},
+	expect{"<user@example.com>", "<p><a href='mailto:user@example.com'>user@example.com</a></p>"},
+	expect{"[http://example.com](http://example.com)", "<p><a href='http://example.com'>http://example.com</a></p>"},
+	expect{`[link](test.png)`, "<p><a href='test.png'>link</a></p>"},
+	expect{`[link](test.png "test")`, "<p><a href='test.png' title='test'>link</a></p>"},
+	expect{`[link](a\_b&amp;.html "&quot;test&quot;")`, "<p><a href='a_b&amp;.html' title='&#34;test&#34;'>link</a></p>"},
+	expect{`[Go](https://en.wikipedia.org/wiki/Go_(programming_language))`, "<p><a href='https://en.wikipedia.org/wiki/Go_(programming_language)'>Go</a></p>"},
+	expect{`[link](<a b.html> "two words")`, "<p><a href='a b.html' title='two words'>link</a></p>"},
+	expect{`[link](a.html 'single') [link](a.html (paren))`, "<p><a href='a.html' title='single'>link</a> <a href='a.html' title='paren'>link</a></p>"},
+	expect{`[link](a\).html "say \"hi\"")`, "<p><a href='a).html' title='say &#34;hi&#34;'>link</a></p>"},
+	expect{"[a `]` b](a.html)", "<p><a href='a.html'>a <code>]</code> b</a></p>"},
+	expect{`[link](a.html "unclosed)`, `<p>[link](a.html &#34;unclosed)</p>`},
+	expect{`[link] (a.html)`, `<p>[link] (a.html)</p>`},
+	expect{`![img](test.png)`, "<p><img src='test.png' alt='img'/></p>"},
+	expect{`![img](test.png "test")`, "<p><img src='test.png' alt='img' title='test'/></p>"},
+	expect{`[![img](test.png)](test)`, "<p><a href='test'><img src='test.png' alt='img'/></a></p>"},
+	expect{`[![img](test.png) ![img](test.png)](test)`, "<p><a href='test'><img src='test.png' alt='img'/> <img src='test.png' alt='img'/></a></p>"},
+
+	// block
+	expect{"# hello", `<h1>hello</h1>`},
+	expect{"## hello", `<h2>hello</h2>`},
+	expect{"## Setup {#setup .beta .x}", "<h2 id='setup' class='beta x'>Setup</h2>"},
+	expect{"## Setup {not attrs}", "<h2>Setup {not attrs}</h2>"},
+	expect{"![logo](a.png){.small width=80 onclick=x}", "<p><img src='a.png' alt='logo' class='small' width='80'/></p>"},
+	expect{"[a](a.html){#l title=\"x y\" href=b.html} {.c}", "<p><a href='a.html' title='x y' id='l'>a</a> {.c}</p>"},
+	expect{"[a](){href=javascript:alert(1) data-x=1}", "<p><a data-x='1'>a</a></p>"},
+	expect{"![a](b.png){style=\"position:fixed\" src=c.png aria-label=x}", "<p><img src='b.png' alt='a' aria-label='x'/></p>"},
+	expect{"```go {#main .numbered}\n```", "<pre><code class='lang_go numbered' id='main'></code></pre>"},
+	expect{"----------", "<hr/>"},
+	expect{"> quote\n> aaa", "<blockquote>quote\naaa\n</blockquote>"},
+	expect{"> quote  \n> aaa", "<blockquote>quote<br/>\naaa\n</blockquote>"},
+	expect{"|a|b|\n|-|-|\n|1|2|\n", "<table>\n<thead>\n<tr><th>a</th><th>b</th></tr>\n</thead>\n<tbody>\n<tr><td>1</td><td>2</td></tr>\n</tbody>\n</table>"},
+	expect{"| a | b |\n|:--|--:|\n| 1 | 2 |\n", "<table>\n<thead>\n<tr><th style='text-align:left'>a</th><th style='text-align:right'>b</th></tr>\n</thead>\n<tbody>\n<tr><td style='text-align:left'>1</td><td style='text-align:right'>2</td></tr>\n</tbody>\n</table>"},
+	expect{"a | b\n--|--\n1 | 2\n\nc", "<table>\n<thead>\n<tr><th>a</th><th>b</th></tr>\n</thead>\n<tbody>\n<tr><td>1</td><td>2</td></tr>\n</tbody>\n</table>\n<p>c</p>"},
+	expect{"a | b\nc | d", "<p>a | b\nc | d</p>"},
+	expect{"|a|b|\n|-|-|\n|\\||`x|y`|\n", "<table>\n<thead>\n<tr><th>a</th><th>b</th></tr>\n</thead>\n<tbody>\n<tr><td>|</td><td><code>x|y</code></td></tr>\n</tbody>\n</table>"},
+	expect{"|a|b|\n|-|-|\n|1|\n|1|2|3|\n", "<table>\n<thead>\n<tr><th>a</th><th>b</th></tr>\n</thead>\n<tbody>\n<tr><td>1</td><td></td></tr>\n<tr><td>1</td><td>2</td></tr>\n</tbody>\n</table>"},
+	expect{"|a||b|\n|-|-|-|\n|1|2|3|\n", "<table>\n<thead>\n<tr><th colspan='2'>a</th><th>b</th></tr>\n</thead>\n<tbody>\n<tr><td>1</td><td>2</td><td>3</td></tr>\n</tbody>\n</table>"},
+	expect{"[Data]\n|a|b|\n|1|2||\n", "<table>\n<caption>Data</caption>\n<thead>\n<tr><th>a</th><th>b</th></tr>\n</thead>\n<tbody>\n<tr><td>1</td><td>2</td></tr>\n</tbody>\n</table>"},
+	expect{"|a|b|\n|1|2|\n[*Data*]\n", "<table>\n<caption><em>Data</em></caption>\n<thead>\n<tr><th>a</th><th>b</th></tr>\n</thead>\n<tbody>\n<tr><td>1</td><td>2</td></tr>\n</tbody>\n</table>"},
+	expect{"|a|b|\n|-|-|\n|1|2| \\\n|3| |\n", "<table>\n<thead>\n<tr><th>a</th><th>b</th></tr>\n</thead>\n<tbody>\n<tr><td>1<br/>\n3</td><td>2</td></tr>\n</tbody>\n</table>"},
+	expect{"- item1\n- item2\n", "<ul>\n<li>item1</li>\n<li>item2</li>\n</ul>"},
+	expect{"1. item1\n2. item2\n", "<ol>\n<li>item1</li>\n<li>item2</li>\n</ol>"},
+	expect{"- [ ] hoge", "<ul>\n<li><input type='checkbox'/>hoge</li>\n</ul>"},
+	expect{"- [x] fuga", "<ul>\n<li><input type='checkbox' checked='checked'/>fuga</li>\n</ul>"},
+	expect{"[dummy]: # (dummy ref)", ""},
+	expect{"The API and HTML APIs.\n\n*[API]: Application Programming Interface\n*[HTML]: Hyper Text",
+		"<p>The <abbr title='Application Programming Interface'>API</abbr> and <abbr title='Hyper Text'>HTML</abbr> APIs.</p>"},
+	expect{"*[API]: Application Programming Interface\n`API` [API](a.html) **API**",
+		"<p><code>API</code> <a href='a.html'>API</a> <strong><abbr title='Application Programming Interface'>API</abbr></strong></p>"},
+	expect{"&dummy_plugin{\ndummy\n}", ""},
+
+	// code
+	expect{"```go\n// test\nfunc main() {\nfmt.Print(\"hello!\")\n}\n```",
+		strings.Replace(
+			`<pre><code class='lang_go'><span class='code_comment'>// test</span>
+			<span class='code_key'>func</span> <span class='code_ident'>main</span>() {
+			<span class='code_ident'>fmt</span>.<span class='code_ident'>Print</span>(<span class='code_str'>&#34;hello!&#34;</span>)
+			}
+			</code></pre>`, "\t", "", -1)},
+	expect{"``` rb\n# sample\ndef main()\nputs \"hello!\"\nend\n```",
+		strings.Replace(
+			`<pre><code class='lang_rb'><span class='code_comment'># sample</span>
+			<span class='code_key'>def</span> <span class='code_ident'>main</span>()
+			<span class='code_ident'>puts</span> <span class='code_str'>&#34;hello!&#34;</span>
+			<span class='code_key'>end</span>
+			</code></pre>`, "\t", "", -1)},
+}
+
 func TestConvert(t *testing.T) {
+	for _, test := range convertTests {
+		in := strings.NewReader(test.input)
+		var out bytes.Buffer
+		writer := NewHTMLWriter(&out)
+		err := Convert(bufio.NewScanner(in), writer)
+		if err != nil {
+			t.Errorf("error %v", err)
+		}
+		writer.Close()
+
+		if strings.TrimSpace(out.String()) != test.expected {
+			t.Errorf("got '%v'\nwant '%v'", out.String(), test.expected)
+		}
 
-	tests := []expect{
-		// inline
-		expect{"hello\nworld", "<p>hello\nworld</p>"},
-		expect{"hello\n\nworld", "<p>hello</p>\n<p>world</p>"},
-		expect{`~~hello~~`, `<p><strike>hello</strike></p>`},
-		expect{`**hello**`, `<p><strong>hello</strong></p>`},
-		expect{`*hello*`, `<p><em>hello</em></p>`},
-		expect{`~~**hello**~~`, `<p><strike><strong>hello</strong></strike></p>`},
-		expect{"`this is code.`", `<p><code>this is code.</code></p>`},
-		expect{"``this is `code`.``", "<p><code>this is `code`.</code></p>"},
-		expect{`\*escaped*`, `<p>*escaped*</p>`},
-		expect{`aaa ** bbb`, `<p>aaa ** bbb</p>`},
-		expect{"url: http://www.example.com/?hello", "<p>url: <a href='http://www.example.com/?hello'>http://www.example.com/?hello</a></p>"},
-		expect{`[link](test.png)`, "<p><a href='test.png'>link</a></p>"},
-		expect{`[link](test.png "test")`, "<p><a href='test.png' title='test'>link</a></p>"},
-		expect{`![img](test.png)`, "<p><img src='test.png' alt='img'/></p>"},
-		expect{`![img](test.png "test")`, "<p><img src='test.png' alt='img' title='test'/></p>"},
-		expect{`[![img](test.png)](test)`, "<p><a href='test'><img src='test.png' alt='img'/></a></p>"},
-		expect{`[![img](test.png) ![img](test.png)](test)`, "<p><a href='test'><img src='test.png' alt='img'/> <img src='test.png' alt='img'/></a></p>"},
-
-		// block
-		expect{"# hello", `<h1>hello</h1>`},
-		expect{"## hello", `<h2>hello</h2>`},
-		expect{"----------", "<hr/>"},
-		expect{"> quote\n> aaa", "<blockquote>quote\naaa\n</blockquote>"},
-		expect{"|a|b|\n|-|-|\n|1|2|\n", "<table>\n<tr><th>a</th><th>b</th></tr>\n<tr><td>1</td><td>2</td></tr>\n</table>"},
-		expect{"- item1\n- item2\n", "<ul>\n<li>item1</li>\n<li>item2</li>\n</ul>"},
-		expect{"1. item1\n2. item2\n", "<ol>\n<li>item1</li>\n<li>item2</li>\n</ol>"},
-		expect{"- [ ] hoge", "<ul>\n<li><input type='checkbox'/>hoge</li>\n</ul>"},
-		expect{"- [x] fuga", "<ul>\n<li><input type='checkbox' checked='checked'/>fuga</li>\n</ul>"},
-		expect{"[dummy]: # (dummy ref)", ""},
-		expect{"&dummy_plugin{\ndummy\n}", ""},
-
-		// code
-		expect{"```go\n// test\nfunc main() {\nfmt.Print(\"hello!\")\n}\n```",
-			strings.Replace(
-				`<pre><code class='lang_go'><span class='code_comment'>// test</span>
-				<span class='code_key'>func</span> <span class='code_ident'>main</span>() {
-				<span class='code_ident'>fmt</span>.<span class='code_ident'>Print</span>(<span class='code_str'>&#34;hello!&#34;</span>)
-				}
-				</code></pre>`, "\t", "", -1)},
-		expect{"``` rb\n# sample\ndef main()\nputs \"hello!\"\nend\n```",
-			strings.Replace(
-				`<pre><code class='lang_rb'><span class='code_comment'># sample</span>
-				<span class='code_key'>def</span> <span class='code_ident'>main</span>()
-				<span class='code_ident'>puts</span> <span class='code_str'>&#34;hello!&#34;</span>
-				<span class='code_key'>end</span>
-				</code></pre>`, "\t", "", -1)},
 	}
+}
 
+func testConvert(t *testing.T, md *Markdown, tests []expect) {
 	for _, test := range tests {
-		in := strings.NewReader(test.input)
 		var out bytes.Buffer
 		writer := NewHTMLWriter(&out)
-		err := Convert(bufio.NewScanner(in), writer)
+		err := md.Convert(bufio.NewScanner(strings.NewReader(test.input)), writer)
 		if err != nil {
 			t.Errorf("error %v", err)
 		}
@@ -79,7 +155,179 @@ func TestConvert(t *testing.T) {
 		if strings.TrimSpace(out.String()) != test.expected {
 			t.Errorf("got '%v'\nwant '%v'", out.String(), test.expected)
 		}
+	}
+}
+
+func TestSoftBreak(t *testing.T) {
+	tests := map[int]string{
+		SoftBreakNewline: "<p>hello\nworld<br/>\n!</p>",
+		SoftBreakSpace:   "<p>hello world<br/>\n!</p>",
+		SoftBreakBr:      "<p>hello<br/>\nworld<br/>\n!</p>",
+	}
+
+	for mode, expected := range tests {
+		var out bytes.Buffer
+		writer := NewHTMLWriter(&out)
+		writer.SoftBreak = mode
+		err := Convert(bufio.NewScanner(strings.NewReader("hello\nworld  \n!")), writer)
+		if err != nil {
+			t.Errorf("error %v", err)
+		}
+		writer.Close()
+
+		if strings.TrimSpace(out.String()) != expected {
+			t.Errorf("got '%v'\nwant '%v'", out.String(), expected)
+		}
+	}
+}
+
+func TestAutolinkDisabled(t *testing.T) {
+	md := NewMarkdown()
+	md.Autolink = false
+
+	var out bytes.Buffer
+	writer := NewHTMLWriter(&out)
+	err := md.Convert(bufio.NewScanner(strings.NewReader("http://example.com <http://example.com>")), writer)
+	if err != nil {
+		t.Errorf("error %v", err)
+	}
+	writer.Close()
+
+	expected := "<p>http://example.com <a href='http://example.com'>http://example.com</a></p>"
+	if strings.TrimSpace(out.String()) != expected {
+		t.Errorf("got '%v'\nwant '%v'", out.String(), expected)
+	}
+}
+
+func TestTableAlign(t *testing.T) {
+	tests := map[int]string{
+		TableAlignStyle: "<td style='text-align:left'>1</td><td>2</td><td style='text-align:center'>3</td>",
+		TableAlignAttr:  "<td align='left'>1</td><td>2</td><td align='center'>3</td>",
+		TableAlignClass: "<td class='align-left'>1</td><td>2</td><td class='align-center'>3</td>",
+	}
+
+	for mode, expected := range tests {
+		var out bytes.Buffer
+		writer := NewHTMLWriter(&out)
+		writer.TableAlign = mode
+		err := Convert(bufio.NewScanner(strings.NewReader("|a|b|c|\n|:-|-|:-:|\n|1|2|3|")), writer)
+		if err != nil {
+			t.Errorf("error %v", err)
+		}
+		writer.Close()
+
+		if !strings.Contains(out.String(), expected) {
+			t.Errorf("got '%v'\nwant '%v'", out.String(), expected)
+		}
+	}
+}
 
+func TestOptionalInline(t *testing.T) {
+	md := NewMarkdown()
+	md.AddInline(MarkMatcher, InsertMatcher, SuperscriptMatcher, SubscriptMatcher, RubyMatcher, AozoraRubyMatcher)
+
+	tests := []expect{
+		expect{"==hello==", "<p><mark>hello</mark></p>"},
+		expect{"++hello++", "<p><ins>hello</ins></p>"},
+		expect{"2^10^ H~2~O", "<p>2<sup>10</sup> H<sub>2</sub>O</p>"},
+		expect{"~~hello~~ ~a b~", "<p><strike>hello</strike> ~a b~</p>"},
+		expect{"~~a ~x~ b~~", "<p><strike>a <sub>x</sub> b</strike></p>"},
+		expect{"x^*y*^", "<p>x<sup><em>y</em></sup></p>"},
+		expect{"{漢字|かんじ}です", "<p><ruby>漢字<rt>かんじ</rt></ruby>です</p>"},
+		expect{"青空｜文庫《ぶんこ》 {a|b|c}", "<p>青空<ruby>文庫<rt>ぶんこ</rt></ruby> {a|b|c}</p>"},
+	}
+
+	testConvert(t, md, tests)
+
+	// default
+	var out bytes.Buffer
+	writer := NewHTMLWriter(&out)
+	Convert(bufio.NewScanner(strings.NewReader("==a== ^b^")), writer)
+	writer.Close()
+	if strings.TrimSpace(out.String()) != "<p>==a== ^b^</p>" {
+		t.Errorf("got '%v'", out.String())
+	}
+}
+
+func TestWikiLink(t *testing.T) {
+	md := NewMarkdown()
+	md.AddInline(&WikiLinkMatcher{func(page string) (string, bool) {
+		return "/wiki/" + strings.Replace(page, " ", "_", -1), page != "Missing"
+	}})
+
+	tests := []expect{
+		expect{"see [[Page Name]].", "<p>see <a href='/wiki/Page_Name' class='wikilink'>Page Name</a>.</p>"},
+		expect{"[[Page Name|*label*]]", "<p><a href='/wiki/Page_Name' class='wikilink'><em>label</em></a></p>"},
+		expect{"[[Missing]]", "<p><a href='/wiki/Missing' class='wikilink missing'>Missing</a></p>"},
+		expect{"[[ ]] [[a]b]]", "<p>[[ ]] [[a]b]]</p>"},
+	}
+
+	testConvert(t, md, tests)
+}
+
+func TestReference(t *testing.T) {
+	md := NewMarkdown()
+	resolver := ReferenceResolverFunc(func(ref Reference) (string, bool) {
+		repo := ref.Repo
+		if repo == "" {
+			repo = "org/app"
+		}
+		switch ref.Kind {
+		case RefMention:
+			return "/users/" + ref.ID, ref.ID != "nobody"
+		case RefIssue:
+			return "/" + repo + "/issues/" + ref.ID, true
+		case RefCommit:
+			return "/" + repo + "/commit/" + ref.ID, true
+		}
+		return "", false
+	})
+	md.AddInline(NewReferenceMatchers(resolver)...)
+	md.AddInline(NewCommitMatchers(resolver)...)
+
+	tests := []expect{
+		expect{"thanks @alice (#12)", "<p>thanks <a href='/users/alice'>@alice</a> (<a href='/org/app/issues/12'>#12</a>)</p>"},
+		expect{"fix org/lib#45, @nobody", "<p>fix <a href='/org/lib/issues/45'>org/lib#45</a>, @nobody</p>"},
+		expect{"in 1a2b3c4d and org/lib@0123abc", "<p>in <a href='/org/app/commit/1a2b3c4d'>1a2b3c4d</a> and <a href='/org/lib/commit/0123abc'>org/lib@0123abc</a></p>"},
+		expect{"a@b.com x#1 1234567 defaced 1a2b3c4dz", "<p><a href='mailto:a@b.com'>a@b.com</a> x#1 1234567 defaced 1a2b3c4dz</p>"},
+		expect{"id 123e4567-e89b-12d3 and 1a2b3c4d-x", "<p>id 123e4567-e89b-12d3 and 1a2b3c4d-x</p>"},
+		expect{"[see #1](a.html)", "<p><a href='a.html'>see #1</a></p>"},
+		expect{"#123 fixed", "<p><a href='/org/app/issues/123'>#123</a> fixed</p>"},
+	}
+	testConvert(t, md, tests)
+
+	// commit hashes are not matched without NewCommitMatchers
+	md = NewMarkdown()
+	md.AddInline(NewReferenceMatchers(resolver)...)
+	testConvert(t, md, []expect{
+		expect{"facade1 deadbeef1 cafe123 #3", "<p>facade1 deadbeef1 cafe123 <a href='/org/app/issues/3'>#3</a></p>"},
+	})
+}
+
+func TestCJK(t *testing.T) {
+	md := NewMarkdown()
+	md.CJK = true
+
+	tests := []expect{
+		expect{"日本語の\n文章です。\nEnglish\ntext", "<p>日本語の文章です。\nEnglish\ntext</p>"},
+		expect{"日本語の  \n文章", "<p>日本語の<br/>\n文章</p>"},
+		expect{"これは**強調\nです**", "<p>これは<strong>強調です</strong></p>"},
+		expect{"한국어\n문장", "<p>한국어\n문장</p>"},
+		expect{"**「強調」**です", "<p><strong>「強調」</strong>です</p>"},
+		expect{"これは**「強調」**です", "<p>これは<strong>「強調」</strong>です</p>"},
+		expect{"これは_強調_です", "<p>これは<em>強調</em>です</p>"},
+		expect{"snake_case_names", "<p>snake_case_names</p>"},
+	}
+
+	testConvert(t, md, tests)
+
+	// default
+	var out bytes.Buffer
+	writer := NewHTMLWriter(&out)
+	Convert(bufio.NewScanner(strings.NewReader("日本語の\n**「強調」**です")), writer)
+	writer.Close()
+	if strings.TrimSpace(out.String()) != "<p>日本語の\n**「強調」**です</p>" {
+		t.Errorf("got '%v'", out.String())
 	}
 }
 
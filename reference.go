@@ -0,0 +1,94 @@
+package markdown
+
+import (
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// Reference kinds
+const (
+	RefMention = iota // @user
+	RefIssue          // #123 or org/repo#123
+	RefCommit         // commit hash or org/repo@hash
+)
+
+// Reference is a reference to a user, an issue or a commit.
+type Reference struct {
+	Kind int
+	Repo string // "org/repo" or empty
+	ID   string // user name, issue number or commit hash
+}
+
+// ReferenceResolver returns the URL for a reference. If ok is false, the reference is written as text.
+type ReferenceResolver interface {
+	ResolveReference(ref Reference) (url string, ok bool)
+}
+
+// ReferenceResolverFunc is an adapter to use a function as a ReferenceResolver.
+type ReferenceResolverFunc func(ref Reference) (string, bool)
+
+func (f ReferenceResolverFunc) ResolveReference(ref Reference) (string, bool) {
+	return f(ref)
+}
+
+// ReferenceMatcher matches references of a kind. Re must have two groups, the repository and the ID.
+type ReferenceMatcher struct {
+	PrefixStr string
+	Re        *regexp.Regexp
+	Kind      int
+	Resolver  ReferenceResolver
+}
+
+// NewReferenceMatchers returns matchers for mentions and issues.
+func NewReferenceMatchers(resolver ReferenceResolver) []Matcher {
+	return []Matcher{
+		&ReferenceMatcher{"@", regexp.MustCompile(`^@()([A-Za-z0-9][A-Za-z0-9-]{0,38})`), RefMention, resolver},
+		&ReferenceMatcher{"#", regexp.MustCompile(`^#()([0-9]+)`), RefIssue, resolver},
+		&ReferenceMatcher{"", regexp.MustCompile(`^([A-Za-z0-9_.-]+/[A-Za-z0-9_.-]+)#([0-9]+)`), RefIssue, resolver},
+	}
+}
+
+// NewCommitMatchers returns matchers for commit hashes (7 to 40 hex digits with both digits
+// and letters) and org/repo@hash. Words which look like hashes such as "facade1" are also
+// matched, so the resolver should return false for hashes which are not in the repository.
+func NewCommitMatchers(resolver ReferenceResolver) []Matcher {
+	return []Matcher{
+		&ReferenceMatcher{"", regexp.MustCompile(`^(?:([A-Za-z0-9_.-]+/[A-Za-z0-9_.-]+)@)?([0-9a-f]{7,40})`), RefCommit, resolver},
+	}
+}
+
+func (m *ReferenceMatcher) Prefix() string {
+	return m.PrefixStr
+}
+
+func (m *ReferenceMatcher) CanFollow(prev rune) bool {
+	return isSpaceRune(prev) || isPunctRune(prev) && !strings.ContainsRune("/@#&-._", prev)
+}
+
+func (m *ReferenceMatcher) TryMatch(text string) (int, []string) {
+	match := m.Re.FindStringSubmatch(text)
+	if len(match) < 3 {
+		return -1, nil
+	}
+	next, _ := utf8.DecodeRuneInString(text[len(match[0]):])
+	if isWordRune(next) || next == '/' || next == '@' || m.Kind == RefCommit && next == '-' {
+		return -1, nil
+	}
+	if m.Kind == RefCommit && (!strings.ContainsAny(match[2], "0123456789") || !strings.ContainsAny(match[2], "abcdef")) {
+		return -1, nil
+	}
+	return len(match[0]), match
+}
+
+func (m *ReferenceMatcher) Render(params []string, md *state) {
+	if m.Resolver != nil && md.inLink == 0 {
+		if url, ok := m.Resolver.ResolveReference(Reference{m.Kind, params[1], params[2]}); ok {
+			n := md.Link(url, "", 0)
+			md.Write(params[0])
+			md.End(n)
+			return
+		}
+	}
+	md.Write(params[0])
+}
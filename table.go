@@ -0,0 +1,229 @@
+package markdown
+
+import (
+	"regexp"
+	"strings"
+)
+
+// LookaheadMatcher is implemented by block matchers which depend on the following line.
+type LookaheadMatcher interface {
+	Matcher
+	TryMatchNext(line string, next string) (int, []string)
+}
+
+var tableRowRe = regexp.MustCompile(`^\|(.+)\|$`)
+var tableCaptionRe = regexp.MustCompile(`^\s*\[([^\]]+)\]\s*$`)
+var tableDelimiterRe = regexp.MustCompile(`^\s*\|?\s*:?-+:?\s*(\|\s*:?-+:?\s*)*\|?\s*$`)
+
+// TableMatcher matches pipe tables. The outer pipes of a row can be omitted
+// if the header row is followed by a delimiter row. A "[Caption]" line can be
+// placed before or after the table.
+type TableMatcher struct {
+}
+
+func (m *TableMatcher) Prefix() string {
+	return ""
+}
+
+func (m *TableMatcher) TryMatch(line string) (int, []string) {
+	if !tableRowRe.MatchString(line) {
+		return -1, nil
+	}
+	return len(line), []string{line}
+}
+
+func (m *TableMatcher) TryMatchNext(line string, next string) (int, []string) {
+	if tableCaptionRe.MatchString(line) && tableRowRe.MatchString(next) {
+		return len(line), []string{line}
+	}
+	if l, params := m.TryMatch(line); l > 0 {
+		return l, params
+	}
+	if !strings.Contains(line, "|") || !strings.Contains(next, "|") || !tableDelimiterRe.MatchString(next) {
+		return -1, nil
+	}
+	if tableColumns(splitTableRow(line)) != len(splitTableRow(next)) {
+		return -1, nil
+	}
+	return len(line), []string{line}
+}
+
+func (m *TableMatcher) Render(params []string, md *state) {
+	text := params[0]
+	var caption string
+	if c := tableCaptionRe.FindStringSubmatch(text); c != nil {
+		caption = c[1]
+		md.Scan()
+		text = md.Text()
+	}
+	header := tableRow(text, md)
+	var align []int
+	if next, ok := md.Peek(); ok && tableDelimiterRe.MatchString(next) {
+		md.Scan()
+		for _, c := range splitTableRow(next) {
+			a := AlignDefault
+			if strings.HasPrefix(c.lines[0], ":") {
+				a |= AlignLeft
+			}
+			if strings.HasSuffix(c.lines[0], ":") {
+				a |= AlignRight
+			}
+			align = append(align, a)
+		}
+	}
+	rows := [][]tableCell{header}
+	for md.Scan() {
+		text := md.Text()
+		if strings.TrimSpace(text) == "" || !strings.Contains(text, "|") {
+			md.Retry()
+			break
+		}
+		rows = append(rows, tableRow(text, md))
+	}
+	if next, ok := md.Peek(); ok && caption == "" {
+		if c := tableCaptionRe.FindStringSubmatch(next); c != nil {
+			caption = c[1]
+			md.Scan()
+		}
+	}
+
+	nt := md.Table()
+	if caption != "" {
+		n := md.TableCaption()
+		md.inline(caption)
+		md.End(n)
+	}
+	columns := tableColumns(header)
+	section := md.TableHead()
+	for i, row := range rows {
+		if i == 1 {
+			md.End(section)
+			section = md.TableBody()
+		}
+		nr := md.TableRow()
+		col := 0
+		for _, cell := range row {
+			if col >= columns {
+				break
+			}
+			opt := TableCellOptions{Span: cell.span, Header: i == 0}
+			if col+opt.Span > columns {
+				opt.Span = columns - col
+			}
+			if col < len(align) {
+				opt.Align = align[col]
+			}
+			nc := md.TableCell(opt)
+			for j, line := range cell.lines {
+				if j > 0 {
+					md.LineBreak(true)
+				}
+				md.inline(line)
+			}
+			md.End(nc)
+			col += opt.Span
+		}
+		for ; col < columns; col++ {
+			opt := TableCellOptions{Span: 1, Header: i == 0}
+			if col < len(align) {
+				opt.Align = align[col]
+			}
+			md.End(md.TableCell(opt))
+		}
+		md.End(nr)
+	}
+	md.End(section)
+	md.End(nt)
+}
+
+// tableCell is a cell of a table row.
+type tableCell struct {
+	lines []string
+	span  int
+}
+
+func tableColumns(row []tableCell) int {
+	n := 0
+	for _, cell := range row {
+		n += cell.span
+	}
+	return n
+}
+
+// tableRow parses a table row. A row ending with a backslash continues to the
+// next line and the cells of the following line are joined to the cells of the row.
+func tableRow(text string, md *state) []tableCell {
+	cells := splitTableRow(text)
+	for isContinuedRow(text) && md.Scan() {
+		text = md.Text()
+		for i, c := range splitTableRow(text) {
+			if i < len(cells) && c.lines[0] != "" {
+				cells[i].lines = append(cells[i].lines, c.lines[0])
+			}
+		}
+	}
+	return cells
+}
+
+func isContinuedRow(text string) bool {
+	text = strings.TrimSpace(text)
+	n := len(text) - len(strings.TrimRight(text, "\\"))
+	return n%2 == 1
+}
+
+// splitTableRow splits a table row into trimmed cells. Escaped pipes and
+// pipes in code spans do not split cells. An empty cell between two pipes
+// ("||") extends the span of the preceding cell.
+func splitTableRow(text string) []tableCell {
+	text = strings.TrimSpace(text)
+	if isContinuedRow(text) {
+		text = strings.TrimSpace(text[:len(text)-1])
+	}
+	text = strings.TrimPrefix(text, "|")
+	if strings.HasSuffix(text, "|") && !strings.HasSuffix(text, "\\|") {
+		text = text[:len(text)-1]
+	}
+
+	var cells []tableCell
+	var cell strings.Builder
+	add := func() {
+		if cell.Len() == 0 && len(cells) > 0 {
+			cells[len(cells)-1].span++
+			return
+		}
+		cells = append(cells, tableCell{lines: []string{strings.TrimSpace(cell.String())}, span: 1})
+		cell.Reset()
+	}
+	for pos := 0; pos < len(text); pos++ {
+		switch c := text[pos]; c {
+		case '\\':
+			cell.WriteByte(c)
+			if pos+1 < len(text) {
+				pos++
+				cell.WriteByte(text[pos])
+			}
+		case '`':
+			n := 1
+			for pos+n < len(text) && text[pos+n] == '`' {
+				n++
+			}
+			run := text[pos : pos+n]
+			p := strings.Index(text[pos+n:], run)
+			if p < 0 {
+				cell.WriteString(run)
+				pos += n - 1
+				break
+			}
+			cell.WriteString(run)
+			cell.WriteString(strings.Replace(text[pos+n:pos+n+p], "\\|", "|", -1))
+			cell.WriteString(run)
+			pos += n + p + n - 1
+		case '|':
+			add()
+		default:
+			cell.WriteByte(c)
+		}
+	}
+	add()
+	return cells
+}
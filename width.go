@@ -0,0 +1,71 @@
+package markdown
+
+import (
+	"unicode"
+)
+
+// East Asian Wide (W) and Fullwidth (F) ranges.
+var wideTable = []struct{ lo, hi rune }{
+	{0x1100, 0x115F},
+	{0x231A, 0x231B},
+	{0x2329, 0x232A},
+	{0x2E80, 0x303E},
+	{0x3041, 0x33FF},
+	{0x3400, 0x4DBF},
+	{0x4E00, 0x9FFF},
+	{0xA000, 0xA4CF},
+	{0xA960, 0xA97F},
+	{0xAC00, 0xD7A3},
+	{0xF900, 0xFAFF},
+	{0xFE10, 0xFE19},
+	{0xFE30, 0xFE6F},
+	{0xFF00, 0xFF60},
+	{0xFFE0, 0xFFE6},
+	{0x1B000, 0x1B2FF},
+	{0x1F300, 0x1F64F},
+	{0x1F900, 0x1F9FF},
+	{0x20000, 0x2FFFD},
+	{0x30000, 0x3FFFD},
+}
+
+// isWide reports whether r is an East Asian wide or fullwidth character.
+func isWide(r rune) bool {
+	if r < 0x1100 {
+		return false
+	}
+	for _, t := range wideTable {
+		if r < t.lo {
+			return false
+		}
+		if r <= t.hi {
+			return true
+		}
+	}
+	return false
+}
+
+// isCJK reports whether r is a wide character of a language written without
+// spaces between words. (Hangul is excluded)
+func isCJK(r rune) bool {
+	return isWide(r) && !unicode.Is(unicode.Hangul, r)
+}
+
+// runeWidth returns the number of columns of r on a terminal.
+func runeWidth(r rune) int {
+	switch {
+	case r == 0 || unicode.Is(unicode.Mn, r) || unicode.Is(unicode.Me, r) || unicode.IsControl(r):
+		return 0
+	case isWide(r):
+		return 2
+	}
+	return 1
+}
+
+// stringWidth returns the number of columns of s on a terminal.
+func stringWidth(s string) int {
+	w := 0
+	for _, r := range s {
+		w += runeWidth(r)
+	}
+	return w
+}
@@ -5,21 +5,79 @@ type DocWriter interface {
 	Link(url string, title string, options int) int
 	Image(url string, title, alt string, options int) int
 	Hr() int
+	LineBreak(hard bool) int
 	Strike() int
 	Emphasis() int
 	Strong() int
 	Code() int
+	Mark() int
+	Insert() int
+	Superscript() int
+	Subscript() int
 	Paragraph() int
 	List(mode int) int
 	ListItem() int
 	Table() int
 	TableRow() int
-	TableCell(flags int) int
+	TableCaption() int
+	TableHead() int
+	TableBody() int
+	TableCell(opt TableCellOptions) int
 	CheckBox(checked bool) int
+	Ruby(base string, reading string) int
+	Abbr(text string, title string) int
 	QuoteBlock() int
 	CodeBlock(lang string, title string) int
+	Attributes(attrs Attrs)
 	End(lv int)
 	Write(text string)
 	WriteStyle(text string, className string, color string, flags int)
 	Close()
 }
+
+// FrontMatterWriter is implemented by writers which use front matter. Convert removes
+// front matter ("---", "key: value" lines and "---" at the first line) from the input
+// and passes its key-value pairs with lowercase keys to FrontMatter before the document.
+type FrontMatterWriter interface {
+	FrontMatter(meta map[string]string)
+}
+
+// Link options
+const (
+	LinkWiki    = 1 << iota // link to a wiki page
+	LinkMissing             // link to a page which does not exist
+)
+
+// Table cell alignments.
+const (
+	AlignDefault = 0
+	AlignLeft    = 1
+	AlignRight   = 2
+	AlignCenter  = 3
+)
+
+// TableCellOptions describes a table cell.
+type TableCellOptions struct {
+	Align  int
+	Span   int // number of columns
+	Header bool
+}
+
+// Attr is a key-value pair of an attribute list.
+type Attr struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+// Attrs is an attribute list. DocWriter.Attributes sets it to the next heading, link, image or code block.
+type Attrs []Attr
+
+// Get returns the value of the attribute.
+func (a Attrs) Get(key string) string {
+	for _, attr := range a {
+		if attr.Key == key {
+			return attr.Value
+		}
+	}
+	return ""
+}
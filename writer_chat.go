@@ -0,0 +1,165 @@
+package markdown
+
+import (
+	"io"
+	"strings"
+)
+
+var slackEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+var slackDialect = &markupDialect{
+	escape: slackEscaper.Replace,
+	wraps: map[string][2]string{
+		NodeStrong:   {"*", "*"},
+		NodeEmphasis: {"_", "_"},
+		NodeStrike:   {"~", "~"},
+	},
+	code: func(text string) string {
+		return "`" + slackEscaper.Replace(text) + "`"
+	},
+	heading: func(text string, level int) string {
+		// mrkdwn has no escape character. An asterisk operator keeps "*" from closing the bold text.
+		return "*" + strings.Replace(text, "*", "\u2217", -1) + "*"
+	},
+	link: func(url, text string, auto bool) string {
+		url = strings.NewReplacer("<", "%3C", ">", "%3E", "|", "%7C").Replace(url)
+		if auto {
+			return "<" + url + ">"
+		}
+		return "<" + url + "|" + text + ">"
+	},
+	codeBlock: func(lang, title, code string) string {
+		return "```\n" + slackEscaper.Replace(code) + "```"
+	},
+	quote: func(lines []string) string {
+		return "> " + strings.Join(lines, "\n> ")
+	},
+	hr:     "----",
+	bullet: "•",
+	indent: "    ",
+}
+
+// SlackWriter : impl for DocWriter. It writes Slack mrkdwn on Close.
+type SlackWriter struct {
+	*TreeWriter
+	writer io.Writer
+}
+
+func NewSlackWriter(writer io.Writer) *SlackWriter {
+	return &SlackWriter{TreeWriter: NewTreeWriter(), writer: writer}
+}
+
+func (w *SlackWriter) Close() {
+	w.TreeWriter.Close()
+	io.WriteString(w.writer, slackDialect.render(w.Root))
+	w.TreeWriter = NewTreeWriter()
+}
+
+var discordEscaper = strings.NewReplacer(
+	"\\", "\\\\", "*", "\\*", "_", "\\_", "~", "\\~", "`", "\\`",
+	"|", "\\|", ">", "\\>", "[", "\\[", "]", "\\]", "<", "\\<",
+)
+
+var discordDialect = &markupDialect{
+	escape:    discordEscaper.Replace,
+	lineStart: escapeLineStart,
+	wraps: map[string][2]string{
+		NodeStrong:   {"**", "**"},
+		NodeEmphasis: {"*", "*"},
+		NodeStrike:   {"~~", "~~"},
+		NodeInsert:   {"__", "__"},
+	},
+	code: func(text string) string {
+		if strings.Contains(text, "`") {
+			return "`` " + text + " ``"
+		}
+		return "`" + text + "`"
+	},
+	heading: func(text string, level int) string {
+		if level > 3 {
+			return "**" + text + "**"
+		}
+		return strings.Repeat("#", level) + " " + text
+	},
+	link: func(url, text string, auto bool) string {
+		if auto {
+			return url
+		}
+		return "[" + text + "](<" + url + ">)"
+	},
+	codeBlock: func(lang, title, code string) string {
+		return "```" + lang + "\n" + strings.Replace(code, "```", "`\u200b``", -1) + "```"
+	},
+	quote: func(lines []string) string {
+		return "> " + strings.Join(lines, "\n> ")
+	},
+	hr:     "───",
+	bullet: "-",
+	indent: "  ",
+}
+
+// DiscordWriter : impl for DocWriter. It writes Discord Markdown on Close.
+type DiscordWriter struct {
+	*TreeWriter
+	writer io.Writer
+}
+
+func NewDiscordWriter(writer io.Writer) *DiscordWriter {
+	return &DiscordWriter{TreeWriter: NewTreeWriter(), writer: writer}
+}
+
+func (w *DiscordWriter) Close() {
+	w.TreeWriter.Close()
+	io.WriteString(w.writer, discordDialect.render(w.Root))
+	w.TreeWriter = NewTreeWriter()
+}
+
+var telegramEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", "\"", "&quot;")
+
+var telegramDialect = &markupDialect{
+	escape: telegramEscaper.Replace,
+	wraps: map[string][2]string{
+		NodeStrong:   {"<b>", "</b>"},
+		NodeEmphasis: {"<i>", "</i>"},
+		NodeStrike:   {"<s>", "</s>"},
+		NodeInsert:   {"<u>", "</u>"},
+	},
+	code: func(text string) string {
+		return "<code>" + telegramEscaper.Replace(text) + "</code>"
+	},
+	heading: func(text string, level int) string {
+		return "<b>" + text + "</b>"
+	},
+	link: func(url, text string, auto bool) string {
+		return "<a href=\"" + telegramEscaper.Replace(url) + "\">" + text + "</a>"
+	},
+	codeBlock: func(lang, title, code string) string {
+		class := ""
+		if lang != "" {
+			class = " class=\"language-" + telegramEscaper.Replace(lang) + "\""
+		}
+		return "<pre><code" + class + ">" + telegramEscaper.Replace(code) + "</code></pre>"
+	},
+	quote: func(lines []string) string {
+		return "<blockquote>" + strings.Join(lines, "\n") + "</blockquote>"
+	},
+	hr:     "───",
+	bullet: "•",
+	indent: "  ",
+}
+
+// TelegramWriter : impl for DocWriter. It writes the HTML subset of Telegram Bot API on Close.
+type TelegramWriter struct {
+	*TreeWriter
+	writer io.Writer
+}
+
+func NewTelegramWriter(writer io.Writer) *TelegramWriter {
+	return &TelegramWriter{TreeWriter: NewTreeWriter(), writer: writer}
+}
+
+func (w *TelegramWriter) Close() {
+	w.TreeWriter.Close()
+	io.WriteString(w.writer, telegramDialect.render(w.Root))
+	w.TreeWriter = NewTreeWriter()
+}
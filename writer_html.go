@@ -4,18 +4,67 @@ import (
 	"fmt"
 	"html"
 	"io"
+	"regexp"
+	"strings"
 )
 
 // HTMLWriter : impl for DocWriter
 type HTMLWriter struct {
 	writer    io.Writer
 	closetags []string
+	attrs     Attrs
+
+	// SoftBreak controls rendering of line breaks in a paragraph. (SoftBreakNewline, SoftBreakSpace or SoftBreakBr)
+	SoftBreak int
+	// TableAlign controls rendering of alignment of table cells. (TableAlignStyle, TableAlignAttr or TableAlignClass)
+	TableAlign int
+	// InlineStyles adds style attributes to elements for HTML without style sheets such as email.
+	// Keys are tag names ("p", "h1", "td", ...), "pre code" for code blocks and classes of code tokens ("code_key", ...).
+	InlineStyles map[string]string
+}
+
+// DefaultInlineStyles are styles for InlineStyles based on examples/theme/style.css.
+var DefaultInlineStyles = map[string]string{
+	"h1":           "font-weight:bold;margin:8pt 0pt 8pt;font-size:20pt;border-bottom:1px solid #ddd",
+	"h2":           "font-weight:bold;margin:8pt 0pt 6pt;font-size:16pt",
+	"h3":           "font-weight:bold;margin:8pt 0pt 6pt;font-size:12pt",
+	"h4":           "font-weight:bold;margin:6pt 0pt 4pt;font-size:10pt",
+	"p":            "margin:4pt 0pt 10pt;padding:0pt",
+	"a":            "color:#4466ee",
+	"ul":           "margin:0pt;padding:0pt 16pt",
+	"ol":           "margin:0pt;padding:0pt 16pt",
+	"table":        "margin:10pt 10pt;border-collapse:collapse",
+	"td":           "padding:4pt;border:solid 1pt #cccccc;min-width:30pt",
+	"th":           "padding:4pt;border:solid 1pt #cccccc;background-color:#ced;min-width:30pt",
+	"img":          "margin:0pt;max-width:100%",
+	"pre":          "margin:0 0 8pt;padding:6pt;word-break:break-all;word-wrap:break-word;background-color:#f8f8f8;border:1px solid #cccccc;border-radius:3pt;tab-size:4",
+	"blockquote":   "margin:0 0 8pt;padding:6pt;border:solid #cccccc;border-width:0px 0px 0px 4pt",
+	"code":         "color:#550000;background-color:#f0f0f0",
+	"code_str":     "color:#992200",
+	"code_key":     "color:#3355cc",
+	"code_num":     "color:#aa0000",
+	"code_ident":   "color:#000000",
+	"code_comment": "color:#228022",
 }
 
+// SoftBreak modes
+const (
+	SoftBreakNewline = iota
+	SoftBreakSpace
+	SoftBreakBr
+)
+
+// TableAlign modes
+const (
+	TableAlignStyle = iota
+	TableAlignAttr
+	TableAlignClass
+)
+
 var DUMMY_DEPTH = 999999
 
 func NewHTMLWriter(writer io.Writer) *HTMLWriter {
-	return &HTMLWriter{writer, make([]string, 10)}
+	return &HTMLWriter{writer: writer, closetags: make([]string, 10)}
 }
 
 type kv struct {
@@ -23,82 +72,181 @@ type kv struct {
 	v string
 }
 
+var attrNameRe = regexp.MustCompile(`^[A-Za-z_:][-A-Za-z0-9_:.]*$`)
+
 func buildTag(tag string, end string, attrs ...kv) string {
 	for _, kv := range attrs {
-		if kv.v != "" {
+		if kv.v != "" && attrNameRe.MatchString(kv.k) && !strings.HasPrefix(strings.ToLower(kv.k), "on") {
 			tag += " " + kv.k + "='" + html.EscapeString(kv.v) + "'"
 		}
 	}
 	return tag + end
 }
 
+// safeAttrs are attributes which an attribute list can set. Attributes which take
+// URLs, scripts or styles, such as href, src, on* and style, are not allowed.
+var safeAttrs = map[string]bool{
+	"id": true, "class": true, "title": true, "lang": true, "dir": true, "role": true,
+	"width": true, "height": true, "alt": true, "start": true, "loading": true,
+}
+
+func isSafeAttr(key string) bool {
+	key = strings.ToLower(key)
+	return safeAttrs[key] || strings.HasPrefix(key, "data-") || strings.HasPrefix(key, "aria-")
+}
+
+// withAttrs appends the attributes set by Attributes to attrs.
+// Classes are joined and other non-empty attributes are not overwritten.
+// Attributes other than safeAttrs, data-* and aria-* are ignored.
+func (w *HTMLWriter) withAttrs(attrs ...kv) []kv {
+	extra := w.attrs
+	w.attrs = nil
+	for _, a := range extra {
+		if !isSafeAttr(a.Key) {
+			continue
+		}
+		found := false
+		for i := range attrs {
+			if attrs[i].k == a.Key {
+				found = true
+				if a.Key == "class" {
+					attrs[i].v = strings.TrimSpace(attrs[i].v + " " + a.Value)
+				} else if attrs[i].v == "" {
+					attrs[i].v = a.Value
+				}
+			}
+		}
+		if !found {
+			attrs = append(attrs, kv{a.Key, a.Value})
+		}
+	}
+	return attrs
+}
+
+// style returns the style attribute for the key of InlineStyles followed by style.
+func (w *HTMLWriter) style(key string, style string) kv {
+	if s := strings.TrimSuffix(w.InlineStyles[key], ";"); s != "" && style != "" {
+		style = s + ";" + style
+	} else if s != "" {
+		style = s
+	}
+	return kv{"style", style}
+}
+
 func (w *HTMLWriter) closeTag(t string) int {
 	w.closetags = append(w.closetags, t)
 	return len(w.closetags) - 1
 }
 func (w *HTMLWriter) simple(t string) int {
-	io.WriteString(w.writer, "<"+t+">")
+	io.WriteString(w.writer, buildTag("<"+t, ">", w.style(t, "")))
 	return w.closeTag("</" + t + ">")
 }
 
 func (w *HTMLWriter) Heading(text string, level int) int {
 	h := fmt.Sprint(level)
-	io.WriteString(w.writer, "<h"+h+">"+html.EscapeString(text)+"</h"+h+">\n")
+	io.WriteString(w.writer, buildTag("<h"+h, ">", w.withAttrs(w.style("h"+h, ""))...)+html.EscapeString(text)+"</h"+h+">\n")
 	return DUMMY_DEPTH
 }
 
 func (w *HTMLWriter) Paragraph() int {
-	io.WriteString(w.writer, "<p>")
+	io.WriteString(w.writer, buildTag("<p", ">", w.style("p", "")))
 	return w.closeTag("</p>\n")
 }
 
 func (w *HTMLWriter) Link(url string, title string, opt int) int {
-	io.WriteString(w.writer, buildTag("<a", ">", kv{"href", url}, kv{"title", title}))
+	class := ""
+	if opt&LinkWiki != 0 {
+		class = "wikilink"
+	}
+	if opt&LinkMissing != 0 {
+		class = strings.TrimSpace(class + " missing")
+	}
+	io.WriteString(w.writer, buildTag("<a", ">", w.withAttrs(kv{"href", url}, kv{"title", title}, kv{"class", class}, w.style("a", ""))...))
 	return w.closeTag("</a>")
 }
 
 func (w *HTMLWriter) Image(url string, title, alt string, opt int) int {
-	io.WriteString(w.writer, buildTag("<img", "/>", kv{"src", url}, kv{"alt", alt}, kv{"title", title}))
+	io.WriteString(w.writer, buildTag("<img", "/>", w.withAttrs(kv{"src", url}, kv{"alt", alt}, kv{"title", title}, w.style("img", ""))...))
 	return DUMMY_DEPTH
 }
 
 func (w *HTMLWriter) Hr() int {
-	io.WriteString(w.writer, "<hr/>")
+	io.WriteString(w.writer, buildTag("<hr", "/>", w.style("hr", "")))
+	return DUMMY_DEPTH
+}
+
+func (w *HTMLWriter) LineBreak(hard bool) int {
+	if hard || w.SoftBreak == SoftBreakBr {
+		io.WriteString(w.writer, "<br/>\n")
+	} else if w.SoftBreak == SoftBreakSpace {
+		io.WriteString(w.writer, " ")
+	} else {
+		io.WriteString(w.writer, "\n")
+	}
 	return DUMMY_DEPTH
 }
 
 func (w *HTMLWriter) List(mode int) int {
-	if mode == 0 {
-		w.writer.Write([]byte("<ul>\n"))
-		return w.closeTag("</ul>\n")
+	tag := "ul"
+	if mode != 0 {
+		tag = "ol"
 	}
-	w.writer.Write([]byte("<ol>\n"))
-	return w.closeTag("</ol>\n")
+	io.WriteString(w.writer, buildTag("<"+tag, ">\n", w.style(tag, "")))
+	return w.closeTag("</" + tag + ">\n")
 }
 
 func (w *HTMLWriter) ListItem() int {
-	w.writer.Write([]byte("<li>"))
+	io.WriteString(w.writer, buildTag("<li", ">", w.style("li", "")))
 	return w.closeTag("</li>\n")
 }
 
 func (w *HTMLWriter) Table() int {
-	w.writer.Write([]byte("<table>\n"))
+	io.WriteString(w.writer, buildTag("<table", ">\n", w.style("table", "")))
 	return w.closeTag("</table>\n")
 }
 
 func (w *HTMLWriter) TableRow() int {
-	w.writer.Write([]byte("<tr>"))
+	io.WriteString(w.writer, buildTag("<tr", ">", w.style("tr", "")))
 	return w.closeTag("</tr>\n")
 }
 
-func (w *HTMLWriter) TableCell(flags int) int {
-	style := []string{"", "text-align:left", "text-align:right", "text-align:center"}[flags&3]
-	if flags&4 != 0 {
-		io.WriteString(w.writer, buildTag("<th", ">", kv{"style", style}))
-		return w.closeTag("</th>")
+func (w *HTMLWriter) TableCaption() int {
+	io.WriteString(w.writer, buildTag("<caption", ">", w.style("caption", "")))
+	return w.closeTag("</caption>\n")
+}
+
+func (w *HTMLWriter) TableHead() int {
+	io.WriteString(w.writer, buildTag("<thead", ">\n", w.style("thead", "")))
+	return w.closeTag("</thead>\n")
+}
+
+func (w *HTMLWriter) TableBody() int {
+	io.WriteString(w.writer, buildTag("<tbody", ">\n", w.style("tbody", "")))
+	return w.closeTag("</tbody>\n")
+}
+
+func (w *HTMLWriter) TableCell(opt TableCellOptions) int {
+	var align, class, style string
+	if a := []string{"", "left", "right", "center"}[opt.Align&3]; a != "" {
+		switch w.TableAlign {
+		case TableAlignAttr:
+			align = a
+		case TableAlignClass:
+			class = "align-" + a
+		default:
+			style = "text-align:" + a
+		}
+	}
+	span := ""
+	if opt.Span > 1 {
+		span = fmt.Sprint(opt.Span)
+	}
+	tag := "td"
+	if opt.Header {
+		tag = "th"
 	}
-	io.WriteString(w.writer, buildTag("<td", ">", kv{"style", style}))
-	return w.closeTag("</td>")
+	io.WriteString(w.writer, buildTag("<"+tag, ">", kv{"colspan", span}, kv{"align", align}, kv{"class", class}, w.style(tag, style)))
+	return w.closeTag("</" + tag + ">")
 }
 
 func (w *HTMLWriter) CheckBox(checked bool) int {
@@ -106,7 +254,18 @@ func (w *HTMLWriter) CheckBox(checked bool) int {
 	if checked {
 		checkedStr = "checked"
 	}
-	io.WriteString(w.writer, buildTag("<input", "/>", kv{"type", "checkbox"}, kv{"checked", checkedStr}))
+	io.WriteString(w.writer, buildTag("<input", "/>", kv{"type", "checkbox"}, kv{"checked", checkedStr}, w.style("input", "")))
+	return DUMMY_DEPTH
+}
+
+func (w *HTMLWriter) Ruby(base string, reading string) int {
+	io.WriteString(w.writer, buildTag("<ruby", ">", w.style("ruby", ""))+html.EscapeString(base)+
+		buildTag("<rt", ">", w.style("rt", ""))+html.EscapeString(reading)+"</rt></ruby>")
+	return DUMMY_DEPTH
+}
+
+func (w *HTMLWriter) Abbr(text string, title string) int {
+	io.WriteString(w.writer, buildTag("<abbr", ">", kv{"title", title}, w.style("abbr", ""))+html.EscapeString(text)+"</abbr>")
 	return DUMMY_DEPTH
 }
 
@@ -126,6 +285,22 @@ func (w *HTMLWriter) Code() int {
 	return w.simple("code")
 }
 
+func (w *HTMLWriter) Mark() int {
+	return w.simple("mark")
+}
+
+func (w *HTMLWriter) Insert() int {
+	return w.simple("ins")
+}
+
+func (w *HTMLWriter) Superscript() int {
+	return w.simple("sup")
+}
+
+func (w *HTMLWriter) Subscript() int {
+	return w.simple("sub")
+}
+
 func (w *HTMLWriter) QuoteBlock() int {
 	return w.simple("blockquote")
 }
@@ -134,7 +309,8 @@ func (w *HTMLWriter) CodeBlock(lang string, title string) int {
 	if lang != "" {
 		lang = "lang_" + lang
 	}
-	io.WriteString(w.writer, buildTag("<pre><code", ">", kv{"class", lang}, kv{"title", title}))
+	io.WriteString(w.writer, buildTag("<pre", ">", w.style("pre", ""))+
+		buildTag("<code", ">", w.withAttrs(kv{"class", lang}, kv{"title", title}, w.style("pre code", ""))...))
 	return w.closeTag("</code></pre>\n")
 }
 
@@ -143,7 +319,7 @@ func (w *HTMLWriter) WriteStyle(text string, className string, color string, fla
 	if color != "" {
 		style += "color:" + color
 	}
-	io.WriteString(w.writer, buildTag("<span", ">", kv{"class", className}, kv{"style", style}))
+	io.WriteString(w.writer, buildTag("<span", ">", kv{"class", className}, w.style(className, style)))
 	w.Write(text)
 	w.writer.Write([]byte("</span>"))
 }
@@ -152,6 +328,10 @@ func (w *HTMLWriter) Write(text string) {
 	io.WriteString(w.writer, html.EscapeString(text))
 }
 
+func (w *HTMLWriter) Attributes(attrs Attrs) {
+	w.attrs = attrs
+}
+
 func (w *HTMLWriter) End(lv int) {
 	for len(w.closetags) > lv {
 		io.WriteString(w.writer, w.closetags[len(w.closetags)-1])
@@ -0,0 +1,63 @@
+package markdown
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// JSONWriter : impl for DocWriter. It writes the document tree as JSON on Close.
+//
+// The document is an object of type "document". Every node has "type" and
+// optional "children", and other fields are omitted if they are empty:
+//
+//	text       text, style, heading, abbr, ruby: text or base text
+//	level      heading: 1-4
+//	url        link, image
+//	title      link, image, abbr, codeblock (":" and the file name)
+//	alt        image
+//	options    link, image: LinkWiki, LinkMissing
+//	mode       list: 0 bullet, 1 ordered
+//	checked    checkbox
+//	align      tablecell: AlignLeft, AlignRight, AlignCenter
+//	span       tablecell: number of columns
+//	header     tablecell
+//	lang       codeblock
+//	class      style: token class such as "code_key" and "code_str"
+//	color      style
+//	flags      style
+//	hard       linebreak: hard line break
+//	reading    ruby
+//	attrs      array of {"key", "value"} set by an attribute list
+//
+// Node types are the values of NodeDocument, NodeText, ... constants.
+// Document, list and table nodes do not have whitespace-only text children.
+// ReadJSON replays the JSON into a DocWriter.
+type JSONWriter struct {
+	*TreeWriter
+	writer io.Writer
+
+	// Indent indents the output if not empty.
+	Indent string
+}
+
+func NewJSONWriter(writer io.Writer) *JSONWriter {
+	return &JSONWriter{TreeWriter: NewTreeWriter(), writer: writer}
+}
+
+func (w *JSONWriter) Close() {
+	w.TreeWriter.Close()
+	encoder := json.NewEncoder(w.writer)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", w.Indent)
+	encoder.Encode(w.Root)
+	w.TreeWriter = NewTreeWriter()
+}
+
+// ReadJSON reads a document written by JSONWriter and writes it to the writer.
+func ReadJSON(reader io.Reader, writer DocWriter) error {
+	var root Node
+	if err := json.NewDecoder(reader).Decode(&root); err != nil {
+		return err
+	}
+	return Replay(&root, writer)
+}
@@ -0,0 +1,259 @@
+package markdown
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// LaTeXWriter : impl for DocWriter. It writes LaTeX on Close.
+type LaTeXWriter struct {
+	*TreeWriter
+	writer io.Writer
+
+	// Sections are sectioning commands for heading levels 1, 2, ...
+	Sections []string
+	// Standalone wraps the output in a document with required packages.
+	Standalone bool
+}
+
+func NewLaTeXWriter(writer io.Writer) *LaTeXWriter {
+	return &LaTeXWriter{
+		TreeWriter: NewTreeWriter(),
+		writer:     writer,
+		Sections:   []string{"section", "subsection", "subsubsection", "paragraph"},
+	}
+}
+
+const latexPreamble = `\documentclass{article}
+\usepackage{hyperref}
+\usepackage{graphicx}
+\usepackage{listings}
+\usepackage{xcolor}
+\usepackage{amssymb}
+\usepackage[normalem]{ulem}
+\begin{document}
+`
+
+func (w *LaTeXWriter) Close() {
+	w.TreeWriter.Close()
+	blocks := w.blocks(w.Root.Children)
+	if w.Standalone {
+		io.WriteString(w.writer, latexPreamble)
+	}
+	if len(blocks) > 0 {
+		io.WriteString(w.writer, strings.Join(blocks, "\n\n")+"\n")
+	}
+	if w.Standalone {
+		io.WriteString(w.writer, "\\end{document}\n")
+	}
+	w.TreeWriter = NewTreeWriter()
+}
+
+func (w *LaTeXWriter) blocks(nodes []*Node) []string {
+	var blocks []string
+	for _, n := range nodes {
+		s := ""
+		switch n.Type {
+		case NodeParagraph:
+			s = w.inlines(n.Children)
+		case NodeHeading:
+			section := w.Sections[len(w.Sections)-1]
+			if n.Level >= 1 && n.Level <= len(w.Sections) {
+				section = w.Sections[n.Level-1]
+			}
+			s = "\\" + section + "{" + escapeLaTeX(n.Text) + "}"
+			if id := n.Attrs.Get("id"); id != "" {
+				s += "\\label{" + id + "}"
+			}
+		case NodeHr:
+			s = "\\noindent\\rule{\\linewidth}{0.4pt}"
+		case NodeList:
+			s = w.list(n)
+		case NodeTable:
+			s = w.table(n)
+		case NodeQuote:
+			s = "\\begin{quote}\n" + strings.TrimRight(w.inlines(n.Children), "\n") + "\n\\end{quote}"
+		case NodeCodeBlock:
+			var opts []string
+			if lang, ok := latexLanguages[strings.ToLower(n.Lang)]; ok {
+				opts = append(opts, "language="+lang)
+			}
+			if title := strings.TrimPrefix(n.Title, ":"); title != "" {
+				opts = append(opts, "caption={"+escapeLaTeX(title)+"}")
+			}
+			begin := "\\begin{lstlisting}"
+			if len(opts) > 0 {
+				begin += "[" + strings.Join(opts, ",") + "]"
+			}
+			code := n.TextContent()
+			if code != "" && !strings.HasSuffix(code, "\n") {
+				code += "\n"
+			}
+			s = begin + "\n" + code + "\\end{lstlisting}"
+		default:
+			s = strings.TrimSpace(w.inline(n))
+		}
+		if s != "" {
+			blocks = append(blocks, s)
+		}
+	}
+	return blocks
+}
+
+func (w *LaTeXWriter) list(n *Node) string {
+	env := "itemize"
+	if n.Mode != 0 {
+		env = "enumerate"
+	}
+	s := "\\begin{" + env + "}\n"
+	for _, c := range n.Children {
+		switch c.Type {
+		case NodeListItem:
+			s += "\\item " + w.inlines(c.Children) + "\n"
+		case NodeList:
+			s += w.list(c) + "\n"
+		}
+	}
+	return s + "\\end{" + env + "}"
+}
+
+func (w *LaTeXWriter) inlines(nodes []*Node) string {
+	s := ""
+	for _, c := range nodes {
+		s += w.inline(c)
+	}
+	return s
+}
+
+func (w *LaTeXWriter) inline(n *Node) string {
+	switch n.Type {
+	case NodeText, NodeStyle, NodeAbbr:
+		return escapeLaTeX(n.Text)
+	case NodeLineBreak:
+		if n.Hard {
+			return "\\\\\n"
+		}
+		return "\n"
+	case NodeEmphasis:
+		return "\\emph{" + w.inlines(n.Children) + "}"
+	case NodeStrong:
+		return "\\textbf{" + w.inlines(n.Children) + "}"
+	case NodeStrike:
+		return "\\sout{" + w.inlines(n.Children) + "}"
+	case NodeMark:
+		return "\\colorbox{yellow}{" + w.inlines(n.Children) + "}"
+	case NodeInsert:
+		return "\\uline{" + w.inlines(n.Children) + "}"
+	case NodeSuperscript:
+		return "\\textsuperscript{" + w.inlines(n.Children) + "}"
+	case NodeSubscript:
+		return "\\textsubscript{" + w.inlines(n.Children) + "}"
+	case NodeCode:
+		return "\\texttt{" + w.inlines(n.Children) + "}"
+	case NodeLink:
+		if n.TextContent() == n.URL {
+			return "\\url{" + escapeLaTeXURL(n.URL) + "}"
+		}
+		return "\\href{" + escapeLaTeXURL(n.URL) + "}{" + w.inlines(n.Children) + "}"
+	case NodeImage:
+		return "\\includegraphics{" + escapeLaTeXURL(n.URL) + "}"
+	case NodeCheckBox:
+		if n.Checked {
+			return "$\\boxtimes$ "
+		}
+		return "$\\square$ "
+	case NodeRuby:
+		return escapeLaTeX(n.Text + "(" + n.Reading + ")")
+	}
+	return w.inlines(n.Children)
+}
+
+var latexAligns = map[int]string{AlignDefault: "l", AlignLeft: "l", AlignRight: "r", AlignCenter: "c"}
+
+// table renders a tabular. Column alignments are taken from the first row.
+func (w *LaTeXWriter) table(n *Node) string {
+	caption, rows := tableRows(n)
+	if len(rows) == 0 {
+		return ""
+	}
+	var spec string
+	for _, cell := range rows[0] {
+		spec += strings.Repeat(latexAligns[cell.Align], cellSpan(cell))
+	}
+	s := "\\begin{tabular}{" + spec + "}\n\\hline\n"
+	for i, row := range rows {
+		var cells []string
+		for _, cell := range row {
+			content := ""
+			multiline := false
+			for _, c := range cell.Children {
+				if c.Type == NodeLineBreak {
+					content += "\\\\"
+					multiline = true
+				} else {
+					content += w.inline(c)
+				}
+			}
+			if multiline {
+				content = "\\shortstack[" + latexAligns[cell.Align] + "]{" + content + "}"
+			}
+			if cell.Header {
+				content = "\\textbf{" + content + "}"
+			}
+			if cellSpan(cell) > 1 {
+				content = fmt.Sprintf("\\multicolumn{%d}{%s}{%s}", cellSpan(cell), latexAligns[cell.Align], content)
+			}
+			cells = append(cells, content)
+		}
+		s += strings.Join(cells, " & ") + " \\\\\n"
+		if i+1 < len(rows) && len(row) > 0 && row[0].Header && (len(rows[i+1]) == 0 || !rows[i+1][0].Header) {
+			s += "\\hline\n"
+		}
+	}
+	s += "\\hline\n\\end{tabular}"
+	if caption != nil {
+		s = "\\begin{table}[h]\n\\centering\n\\caption{" + w.inlines(caption.Children) + "}\n" + s + "\n\\end{table}"
+	}
+	return s
+}
+
+// latexLanguages maps language names of code blocks to languages of the listings package.
+// Other languages are not passed to lstlisting because unknown ones are errors.
+var latexLanguages = map[string]string{
+	"awk": "awk", "bash": "bash", "sh": "sh", "shell": "bash", "zsh": "bash", "csh": "csh", "ksh": "ksh",
+	"c": "C", "h": "C", "cpp": "C++", "c++": "C++", "cc": "C++", "hpp": "C++",
+	"java": "Java", "python": "Python", "py": "Python", "ruby": "Ruby", "rb": "Ruby",
+	"perl": "Perl", "pl": "Perl", "php": "PHP", "sql": "SQL", "html": "HTML", "xml": "XML",
+	"tex": "TeX", "latex": "{[LaTeX]TeX}", "make": "make", "makefile": "make",
+	"haskell": "Haskell", "hs": "Haskell", "lisp": "Lisp", "ocaml": "Caml", "ml": "ML",
+	"erlang": "erlang", "fortran": "Fortran", "pascal": "Pascal", "delphi": "Delphi",
+	"r": "R", "matlab": "Matlab", "octave": "Octave", "tcl": "tcl", "prolog": "Prolog",
+	"vhdl": "VHDL", "verilog": "Verilog", "scilab": "Scilab", "cobol": "Cobol", "ada": "Ada",
+}
+
+var latexReplacer = strings.NewReplacer(
+	"\\", "\\textbackslash{}",
+	"{", "\\{",
+	"}", "\\}",
+	"$", "\\$",
+	"&", "\\&",
+	"#", "\\#",
+	"%", "\\%",
+	"_", "\\_",
+	"~", "\\textasciitilde{}",
+	"^", "\\textasciicircum{}",
+	"<", "\\textless{}",
+	">", "\\textgreater{}",
+	"|", "\\textbar{}",
+)
+
+// escapeLaTeX escapes LaTeX special characters in text.
+func escapeLaTeX(text string) string {
+	return latexReplacer.Replace(text)
+}
+
+// escapeLaTeXURL escapes characters in an argument of \href, \url and \includegraphics.
+func escapeLaTeXURL(url string) string {
+	return strings.NewReplacer("\\", "\\\\", "#", "\\#", "%", "\\%", "{", "\\{", "}", "\\}").Replace(url)
+}
@@ -0,0 +1,258 @@
+package markdown
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// ManWriter : impl for DocWriter. It writes a man(7) page on Close.
+//
+// The title line (.TH) is taken from front matter with keys title, section,
+// date, source and manual (see FrontMatterWriter), or from the first heading
+// such as "foo(1)". The shallowest remaining headings become .SH and deeper ones .SS.
+type ManWriter struct {
+	*TreeWriter
+	writer io.Writer
+
+	// Default values of the title line.
+	Section string
+	Date    string
+	Source  string
+	Manual  string
+
+	front map[string]string
+}
+
+func NewManWriter(writer io.Writer) *ManWriter {
+	return &ManWriter{TreeWriter: NewTreeWriter(), writer: writer, Section: "1"}
+}
+
+var manTitleRe = regexp.MustCompile(`^(\S+?)\((\w+)\)`)
+
+// FrontMatter : impl for FrontMatterWriter.
+func (w *ManWriter) FrontMatter(meta map[string]string) {
+	w.front = meta
+}
+
+func (w *ManWriter) Close() {
+	w.TreeWriter.Close()
+	var nodes []*Node
+	for _, n := range w.Root.Children {
+		if n.Type != NodeText || strings.TrimSpace(n.Text) != "" {
+			nodes = append(nodes, n)
+		}
+	}
+	meta := map[string]string{"section": w.Section, "date": w.Date, "source": w.Source, "manual": w.Manual}
+	if w.front != nil {
+		for k, v := range w.front {
+			meta[k] = v
+		}
+	} else {
+		for i, n := range nodes {
+			if n.Type == NodeHeading {
+				meta["title"] = n.Text
+				if m := manTitleRe.FindStringSubmatch(n.Text); m != nil {
+					meta["title"], meta["section"] = m[1], m[2]
+				}
+				nodes = append(nodes[:i:i], nodes[i+1:]...)
+				break
+			}
+		}
+	}
+
+	top := 0
+	for _, n := range nodes {
+		if n.Type == NodeHeading && (top == 0 || n.Level < top) {
+			top = n.Level
+		}
+	}
+	var out []string
+	if w.hasTable(nodes) {
+		out = append(out, `'\" t`)
+	}
+	out = append(out, ".TH "+manArgs(strings.ToUpper(meta["title"]), meta["section"], meta["date"], meta["source"], meta["manual"]))
+	for _, n := range nodes {
+		switch n.Type {
+		case NodeHeading:
+			macro := ".SH "
+			if n.Level > top {
+				macro = ".SS "
+			}
+			out = append(out, macro+manArgs(n.Text))
+		case NodeParagraph:
+			out = append(out, ".PP", w.inlines(n.Children))
+		case NodeHr:
+			out = append(out, ".PP", `\l'\n(.lu'`)
+		case NodeList:
+			out = append(out, w.list(n)...)
+		case NodeTable:
+			out = append(out, w.table(n)...)
+		case NodeQuote:
+			out = append(out, ".RS 4", strings.TrimRight(w.inlines(n.Children), "\n"), ".RE")
+		case NodeCodeBlock:
+			code := strings.TrimSuffix(n.TextContent(), "\n")
+			out = append(out, ".PP", ".EX", escapeRoff(code), ".EE")
+		default:
+			if s := strings.TrimSpace(w.inline(n)); s != "" {
+				out = append(out, ".PP", s)
+			}
+		}
+	}
+	io.WriteString(w.writer, strings.Join(out, "\n")+"\n")
+	w.TreeWriter = NewTreeWriter()
+	w.front = nil
+}
+
+func (w *ManWriter) hasTable(nodes []*Node) bool {
+	for _, n := range nodes {
+		if n.Type == NodeTable {
+			return true
+		}
+	}
+	return false
+}
+
+// list renders list items with .IP, or with .TP if an item starts with code or strong text.
+func (w *ManWriter) list(n *Node) []string {
+	var out []string
+	num := 1
+	for _, c := range n.Children {
+		switch c.Type {
+		case NodeListItem:
+			children := c.Children
+			if len(children) > 0 && (children[0].Type == NodeCode || children[0].Type == NodeStrong) {
+				// drop the separator between the tag and the description. "-" is already escaped.
+				rest := strings.TrimLeft(w.inlines(children[1:]), " ")
+				rest = strings.TrimPrefix(strings.TrimPrefix(rest, ":"), `\-`)
+				rest = strings.TrimLeft(rest, " ")
+				out = append(out, ".TP", w.inline(children[0]), rest)
+				continue
+			}
+			marker := `\(bu 2`
+			if n.Mode != 0 {
+				marker = fmt.Sprintf("%d. 4", num)
+				num++
+			}
+			out = append(out, ".IP "+marker, w.inlines(children))
+		case NodeList:
+			out = append(out, ".RS")
+			out = append(out, w.list(c)...)
+			out = append(out, ".RE")
+		}
+	}
+	return out
+}
+
+func (w *ManWriter) inlines(nodes []*Node) string {
+	s := ""
+	for _, c := range nodes {
+		s += w.inline(c)
+	}
+	return s
+}
+
+func (w *ManWriter) inline(n *Node) string {
+	switch n.Type {
+	case NodeText, NodeStyle, NodeAbbr:
+		return escapeRoff(n.Text)
+	case NodeLineBreak:
+		if n.Hard {
+			return "\n.br\n"
+		}
+		return "\n"
+	case NodeEmphasis:
+		return `\fI` + w.inlines(n.Children) + `\fP`
+	case NodeStrong, NodeCode:
+		return `\fB` + w.inlines(n.Children) + `\fP`
+	case NodeSuperscript:
+		return "^" + w.inlines(n.Children)
+	case NodeSubscript:
+		return "_" + w.inlines(n.Children)
+	case NodeLink:
+		text := w.inlines(n.Children)
+		url := strings.TrimPrefix(n.URL, "mailto:")
+		if n.TextContent() == url {
+			return `\fI` + text + `\fP`
+		}
+		return text + ` <\fI` + escapeRoff(url) + `\fP>`
+	case NodeImage:
+		return "[" + escapeRoff(n.Alt) + "]"
+	case NodeCheckBox:
+		if n.Checked {
+			return "[x] "
+		}
+		return "[ ] "
+	case NodeRuby:
+		return escapeRoff(n.Text + "(" + n.Reading + ")")
+	}
+	return w.inlines(n.Children)
+}
+
+var manAligns = map[int]string{AlignDefault: "l", AlignLeft: "l", AlignRight: "r", AlignCenter: "c"}
+
+// table renders a table for tbl(1).
+func (w *ManWriter) table(n *Node) []string {
+	caption, rows := tableRows(n)
+	if len(rows) == 0 {
+		return nil
+	}
+	var out []string
+	if caption != nil {
+		out = append(out, ".PP", w.inlines(caption.Children))
+	}
+	var formats, lines []string
+	for _, row := range rows {
+		var format, cells []string
+		for _, cell := range row {
+			f := manAligns[cell.Align]
+			if cell.Header {
+				f += "B"
+			}
+			format = append(format, f)
+			for i := 1; i < cellSpan(cell); i++ {
+				format = append(format, "s")
+			}
+			content := ""
+			for _, c := range cell.Children {
+				if c.Type == NodeLineBreak {
+					content += "\n"
+				} else {
+					content += w.inline(c)
+				}
+			}
+			if strings.Contains(content, "\n") {
+				content = "T{\n" + content + "\nT}"
+			}
+			cells = append(cells, content)
+		}
+		formats = append(formats, strings.Join(format, " "))
+		lines = append(lines, strings.Join(cells, "\t"))
+	}
+	out = append(out, ".TS", "allbox;")
+	out = append(out, strings.Join(formats, "\n")+".")
+	out = append(out, lines...)
+	return append(out, ".TE")
+}
+
+// escapeRoff escapes backslashes and hyphen-minus signs, and dots and quotes at the beginning of lines.
+// Hyphen-minus is written as \- so that options such as --flag are not rendered as hyphens.
+func escapeRoff(text string) string {
+	text = strings.NewReplacer(`\`, `\e`, "-", `\-`).Replace(text)
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		if strings.HasPrefix(line, ".") || strings.HasPrefix(line, "'") {
+			lines[i] = `\&` + line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// manArgs quotes arguments of a macro.
+func manArgs(args ...string) string {
+	for i, a := range args {
+		args[i] = `"` + strings.Replace(escapeRoff(a), `"`, `\(dq`, -1) + `"`
+	}
+	return strings.Join(args, " ")
+}
@@ -0,0 +1,466 @@
+package markdown
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// MarkdownWriter : impl for DocWriter. It writes normalized Markdown on Close.
+type MarkdownWriter struct {
+	*TreeWriter
+	writer io.Writer
+
+	// BulletMarker is the marker of unordered list items. ("-", "*" or "+")
+	BulletMarker string
+	// Width wraps paragraphs and quotes if > 0.
+	Width int
+	// PadTables pads table cells to align columns.
+	PadTables bool
+
+	abbrs []Node
+}
+
+func NewMarkdownWriter(writer io.Writer) *MarkdownWriter {
+	return &MarkdownWriter{TreeWriter: NewTreeWriter(), writer: writer, BulletMarker: "-", PadTables: true}
+}
+
+func (w *MarkdownWriter) Close() {
+	w.TreeWriter.Close()
+	blocks := w.blocks(w.Root.Children)
+	if len(w.abbrs) > 0 {
+		var defs []string
+		for _, a := range w.abbrs {
+			defs = append(defs, "*["+a.Text+"]: "+a.Title)
+		}
+		blocks = append(blocks, strings.Join(defs, "\n"))
+	}
+	if len(blocks) > 0 {
+		io.WriteString(w.writer, strings.Join(blocks, "\n\n")+"\n")
+	}
+	w.TreeWriter = NewTreeWriter()
+	w.abbrs = nil
+}
+
+func (w *MarkdownWriter) blocks(nodes []*Node) []string {
+	var blocks []string
+	for _, n := range nodes {
+		var lines []string
+		switch n.Type {
+		case NodeParagraph:
+			lines = w.lines(n.Children)
+		case NodeHeading:
+			lines = []string{strings.TrimSpace(strings.Repeat("#", n.Level) + " " + n.Text + " " + formatAttrs(n.Attrs))}
+		case NodeHr:
+			lines = []string{"---"}
+		case NodeList:
+			lines = w.list(n, "")
+		case NodeTable:
+			lines = w.table(n)
+		case NodeQuote:
+			children := n.Children
+			if l := len(children); l > 0 && children[l-1].Type == NodeText {
+				last := *children[l-1]
+				last.Text = strings.TrimRight(last.Text, "\n")
+				children = append(children[:l-1:l-1], &last)
+			}
+			for _, line := range w.lines(children) {
+				lines = append(lines, strings.TrimRight("> "+line, " "))
+			}
+		case NodeCodeBlock:
+			code := n.TextContent()
+			if code != "" && !strings.HasSuffix(code, "\n") {
+				code += "\n"
+			}
+			fence := strings.TrimSpace("```" + n.Lang + n.Title + " " + formatAttrs(n.Attrs))
+			lines = []string{fence + "\n" + code + "```"}
+		default:
+			if s := strings.TrimSpace(w.inline(n, "")); s != "" {
+				lines = []string{s}
+			}
+		}
+		if len(lines) > 0 {
+			blocks = append(blocks, strings.Join(lines, "\n"))
+		}
+	}
+	return blocks
+}
+
+func (w *MarkdownWriter) list(n *Node, indent string) []string {
+	var lines []string
+	num := 1
+	childIndent := indent + "  "
+	for _, c := range n.Children {
+		switch c.Type {
+		case NodeListItem:
+			marker := w.BulletMarker
+			if n.Mode != 0 {
+				marker = fmt.Sprint(num, ".")
+				num++
+			}
+			lines = append(lines, indent+marker+" "+w.inlines(c.Children))
+			childIndent = indent + strings.Repeat(" ", len(marker)+1)
+		case NodeList:
+			lines = append(lines, w.list(c, childIndent)...)
+		}
+	}
+	return lines
+}
+
+// mdSegment is a part of a line. A segment preceded by a space can be moved to the next line.
+type mdSegment struct {
+	text  string
+	space bool
+}
+
+// lines renders inline nodes separated by line breaks.
+func (w *MarkdownWriter) lines(nodes []*Node) []string {
+	var lines [][]mdSegment
+	var cur []mdSegment
+	last := ""
+	space := false // a soft break before the next segment
+	for _, c := range nodes {
+		switch {
+		case c.Type == NodeLineBreak && (c.Hard || w.Width <= 0):
+			if c.Hard {
+				cur = append(cur, mdSegment{text: "\\"})
+			}
+			lines = append(lines, cur)
+			cur = nil
+			last = ""
+		case c.Type == NodeLineBreak:
+			space = true
+		case c.Type == NodeText:
+			for i, word := range strings.Split(escapeMarkdown(c.Text), " ") {
+				cur = append(cur, mdSegment{text: word, space: i > 0 || space})
+				space = false
+			}
+			last = c.Text
+		default:
+			s := w.inline(c, last)
+			if l := len(cur); l > 0 && cur[l-1].text == "" && cur[l-1].space {
+				cur, space = cur[:l-1], true
+			}
+			cur = append(cur, mdSegment{text: s, space: space})
+			space = false
+			last = s
+		}
+	}
+	lines = append(lines, cur)
+
+	var result []string
+	for _, segments := range lines {
+		for _, line := range wrapSegments(segments, w.Width) {
+			result = append(result, escapeLineStart(line))
+		}
+	}
+	return result
+}
+
+func wrapSegments(segments []mdSegment, width int) []string {
+	var lines []string
+	line := ""
+	for _, s := range segments {
+		if s.space && width > 0 && strings.TrimSpace(line) != "" && s.text != "" &&
+			stringWidth(line)+1+stringWidth(s.text) > width {
+			lines = append(lines, strings.TrimRight(line, " "))
+			line = s.text
+			continue
+		}
+		if s.space {
+			line += " "
+		}
+		line += s.text
+	}
+	return append(lines, line)
+}
+
+func (w *MarkdownWriter) inlines(nodes []*Node) string {
+	s := ""
+	for _, c := range nodes {
+		s += w.inline(c, s)
+	}
+	return s
+}
+
+// inline renders an inline node. prev is the text rendered before the node.
+func (w *MarkdownWriter) inline(n *Node, prev string) string {
+	emphasis := "*"
+	if strings.HasSuffix(prev, "*") {
+		emphasis = "_"
+	}
+	switch n.Type {
+	case NodeText:
+		return escapeMarkdown(n.Text)
+	case NodeStyle:
+		return n.Text
+	case NodeLineBreak:
+		if n.Hard {
+			return "\\\n"
+		}
+		return "\n"
+	case NodeEmphasis:
+		return emphasis + w.inlines(n.Children) + emphasis
+	case NodeStrong:
+		return emphasis + emphasis + w.inlines(n.Children) + emphasis + emphasis
+	case NodeStrike:
+		return "~~" + w.inlines(n.Children) + "~~"
+	case NodeMark:
+		return "==" + w.inlines(n.Children) + "=="
+	case NodeInsert:
+		return "++" + w.inlines(n.Children) + "++"
+	case NodeSuperscript:
+		return "^" + w.inlines(n.Children) + "^"
+	case NodeSubscript:
+		return "~" + w.inlines(n.Children) + "~"
+	case NodeCode:
+		code := n.TextContent()
+		if strings.Contains(code, "`") {
+			return "``" + code + "``"
+		}
+		return "`" + code + "`"
+	case NodeLink:
+		text := n.TextContent()
+		if n.Title == "" && len(n.Attrs) == 0 && len(n.Children) == 1 && n.Children[0].Type == NodeText && !strings.ContainsAny(n.URL, " <>") {
+			if text == n.URL && strings.Contains(text, ":") {
+				return "<" + text + ">"
+			}
+			if "mailto:"+text == n.URL {
+				return "<" + text + ">"
+			}
+		}
+		return "[" + w.inlines(n.Children) + "](" + formatLinkDestination(n.URL, n.Title) + ")" + formatAttrs(n.Attrs)
+	case NodeImage:
+		return "![" + n.Alt + "](" + formatLinkDestination(n.URL, n.Title) + ")" + formatAttrs(n.Attrs)
+	case NodeCheckBox:
+		if n.Checked {
+			return "[x] "
+		}
+		return "[ ] "
+	case NodeRuby:
+		return "{" + n.Text + "|" + n.Reading + "}"
+	case NodeAbbr:
+		found := false
+		for _, a := range w.abbrs {
+			found = found || a.Text == n.Text
+		}
+		if !found {
+			w.abbrs = append(w.abbrs, *n)
+		}
+		return escapeMarkdown(n.Text)
+	}
+	return w.inlines(n.Children)
+}
+
+func (w *MarkdownWriter) table(n *Node) []string {
+	var caption string
+	captionNode, rows := tableRows(n)
+	if captionNode != nil {
+		caption = w.inlines(captionNode.Children)
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+
+	columns := 0
+	for _, cell := range rows[0] {
+		columns += cellSpan(cell)
+	}
+	widths := make([]int, columns)
+	aligns := make([]int, columns)
+	aligned := make([]bool, columns)
+	contents := make([][][]string, len(rows))
+	for i, row := range rows {
+		col := 0
+		for _, cell := range row {
+			var segments []string
+			for _, line := range w.cellLines(cell) {
+				segments = append(segments, line)
+				if col < columns && cellSpan(cell) == 1 && stringWidth(line) > widths[col] {
+					widths[col] = stringWidth(line)
+				}
+			}
+			contents[i] = append(contents[i], segments)
+			if col < columns && !aligned[col] {
+				aligns[col] = cell.Align
+				aligned[col] = true
+			}
+			col += cellSpan(cell)
+		}
+	}
+	for i := range widths {
+		if widths[i] < 3 || !w.PadTables {
+			widths[i] = 3
+		}
+	}
+
+	var lines []string
+	continued := false
+	for i, row := range rows {
+		height := 1
+		for _, segments := range contents[i] {
+			if len(segments) > height {
+				height = len(segments)
+			}
+		}
+		for k := 0; k < height; k++ {
+			line := "|"
+			col := 0
+			for j, cell := range row {
+				span := cellSpan(cell)
+				text := ""
+				if k < len(contents[i][j]) {
+					text = contents[i][j][k]
+				}
+				if w.PadTables && col < columns {
+					width := 2 * (span - 1)
+					for c := col; c < col+span && c < columns; c++ {
+						width += widths[c]
+					}
+					pad := width - stringWidth(text)
+					switch aligns[col] {
+					case AlignRight:
+						text = strings.Repeat(" ", pad) + text
+					case AlignCenter:
+						text = strings.Repeat(" ", pad/2) + text + strings.Repeat(" ", pad-pad/2)
+					default:
+						text += strings.Repeat(" ", pad)
+					}
+				}
+				line += " " + text + " " + strings.Repeat("|", span)
+				col += span
+			}
+			if k < height-1 {
+				line += " \\"
+				continued = continued || i == 0
+			}
+			lines = append(lines, line)
+		}
+		if i == 0 {
+			line := "|"
+			for c, width := range widths {
+				dashes := strings.Repeat("-", width)
+				switch aligns[c] {
+				case AlignLeft:
+					dashes = ":" + dashes[1:]
+				case AlignRight:
+					dashes = dashes[1:] + ":"
+				case AlignCenter:
+					dashes = ":" + dashes[2:] + ":"
+				}
+				line += " " + dashes + " |"
+			}
+			lines = append(lines, line)
+		}
+	}
+	if caption != "" {
+		if continued {
+			lines = append(lines, "["+caption+"]")
+		} else {
+			lines = append([]string{"[" + caption + "]"}, lines...)
+		}
+	}
+	return lines
+}
+
+func cellSpan(cell *Node) int {
+	if cell.Span < 1 {
+		return 1
+	}
+	return cell.Span
+}
+
+// cellLines renders a table cell. Hard line breaks split the content into lines.
+func (w *MarkdownWriter) cellLines(cell *Node) []string {
+	lines := []string{""}
+	for _, c := range cell.Children {
+		if c.Type == NodeLineBreak {
+			lines = append(lines, "")
+			continue
+		}
+		lines[len(lines)-1] += w.inline(c, lines[len(lines)-1])
+	}
+	return lines
+}
+
+// escapeMarkdown escapes characters which can be parsed as markup in text.
+func escapeMarkdown(text string) string {
+	var buf strings.Builder
+	for pos := 0; pos < len(text); pos++ {
+		c := text[pos]
+		switch c {
+		case '\\', '`', '*', '[', ']', '<', '~', '|', '^', '{', '}':
+			buf.WriteByte('\\')
+		case '_':
+			prev, _ := utf8.DecodeLastRuneInString(text[:pos])
+			next, _ := utf8.DecodeRuneInString(text[pos+1:])
+			if pos == 0 || pos == len(text)-1 || !isWordRune(prev) || !isWordRune(next) {
+				buf.WriteByte('\\')
+			}
+		case '=', '+':
+			if pos+1 < len(text) && text[pos+1] == c {
+				buf.WriteByte('\\')
+			}
+		case '&':
+			if entityRe.MatchString(text[pos:]) {
+				buf.WriteByte('\\')
+			}
+		}
+		buf.WriteByte(c)
+	}
+	return buf.String()
+}
+
+var mdLineStartRe = regexp.MustCompile(`^(\s*)(#+(?:\s|$)|>|[-+](?:\s|$)|([-_]\s?){3,}$|\d+\.\s|&\w+[{]*$)`)
+
+// escapeLineStart escapes a character at the beginning of a line which can start a block.
+func escapeLineStart(line string) string {
+	m := mdLineStartRe.FindStringSubmatchIndex(line)
+	if m == nil {
+		return line
+	}
+	p := m[3]
+	if line[p] >= '0' && line[p] <= '9' {
+		p = strings.IndexByte(line[p:], '.') + p
+	}
+	return line[:p] + "\\" + line[p:]
+}
+
+func formatLinkDestination(url string, title string) string {
+	dest := strings.NewReplacer("\\", "\\\\", "&", "\\&").Replace(url)
+	if url == "" || strings.ContainsAny(url, " <>") || strings.Count(url, "(") != strings.Count(url, ")") {
+		dest = "<" + strings.NewReplacer("\\", "\\\\", "<", "\\<", ">", "\\>", "&", "\\&").Replace(url) + ">"
+	}
+	if title != "" {
+		dest += " \"" + strings.NewReplacer("\\", "\\\\", "\"", "\\\"", "&", "\\&").Replace(title) + "\""
+	}
+	return dest
+}
+
+func formatAttrs(attrs Attrs) string {
+	if len(attrs) == 0 {
+		return ""
+	}
+	var parts []string
+	for _, a := range attrs {
+		switch {
+		case a.Key == "id":
+			parts = append(parts, "#"+a.Value)
+		case a.Key == "class":
+			for _, c := range strings.Fields(a.Value) {
+				parts = append(parts, "."+c)
+			}
+		case a.Value == "" || strings.ContainsAny(a.Value, " \t}\"'\\&"):
+			quote := "\""
+			if strings.Contains(a.Value, "\"") {
+				quote = "'"
+			}
+			parts = append(parts, a.Key+"="+quote+a.Value+quote)
+		default:
+			parts = append(parts, a.Key+"="+a.Value)
+		}
+	}
+	return "{" + strings.Join(parts, " ") + "}"
+}
@@ -0,0 +1,164 @@
+package markdown
+
+import (
+	"fmt"
+	"strings"
+)
+
+// markupDialect describes a lightweight markup language and renders a document tree with it.
+type markupDialect struct {
+	escape    func(text string) string
+	lineStart func(line string) string // escapes block markup at the beginning of a line
+	wraps     map[string][2]string     // inline node type to opening and closing markup
+	code      func(text string) string
+	heading   func(text string, level int) string
+	link      func(url, text string, auto bool) string
+	codeBlock func(lang, title, code string) string
+	quote     func(lines []string) string
+	hr        string
+	hardBreak string                                 // "\n" if empty
+	image     func(url, alt string) string           // link if nil
+	table     func(d *markupDialect, n *Node) string // text table in a code block if nil
+	checkBox  [2]string                              // unchecked and checked, "☐ " and "☑ " if empty
+
+	// list items are prefixed by bullet or number, and nested lists are indented.
+	bullet string
+	indent string
+	// markers for bullet and ordered list items are used instead of bullet and indent if set.
+	// Nested lists repeat the marker, or append it to the markers of the parents if chain is true.
+	markers [2]string
+	chain   bool
+}
+
+func (r *markupDialect) render(root *Node) string {
+	blocks := r.blocks(root.Children)
+	if len(blocks) == 0 {
+		return ""
+	}
+	return strings.Join(blocks, "\n\n") + "\n"
+}
+
+func (r *markupDialect) blocks(nodes []*Node) []string {
+	var blocks []string
+	for _, n := range nodes {
+		s := ""
+		switch n.Type {
+		case NodeParagraph:
+			s = r.lines(r.inlines(n.Children))
+		case NodeHeading:
+			s = r.heading(r.escape(n.Text), n.Level)
+		case NodeHr:
+			s = r.hr
+		case NodeList:
+			s = strings.Join(r.list(n, ""), "\n")
+		case NodeTable:
+			if r.table != nil {
+				s = r.table(r, n)
+				break
+			}
+			t := &textRenderer{box: strings.Split("+++++++++-|", "")}
+			s = r.codeBlock("", "", strings.Join(t.table(n, 0), "\n")+"\n")
+		case NodeQuote:
+			s = r.quote(strings.Split(r.lines(strings.TrimRight(r.inlines(n.Children), "\n")), "\n"))
+		case NodeCodeBlock:
+			code := n.TextContent()
+			if code != "" && !strings.HasSuffix(code, "\n") {
+				code += "\n"
+			}
+			s = r.codeBlock(n.Lang, strings.TrimPrefix(n.Title, ":"), code)
+		default:
+			s = strings.TrimSpace(r.inline(n))
+		}
+		if s != "" {
+			blocks = append(blocks, s)
+		}
+	}
+	return blocks
+}
+
+// lines escapes the beginning of each line.
+func (r *markupDialect) lines(text string) string {
+	if r.lineStart == nil {
+		return text
+	}
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		lines[i] = r.lineStart(line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// list renders list items. prefix is the indent or the markers of the parents.
+func (r *markupDialect) list(n *Node, prefix string) []string {
+	var lines []string
+	num := 1
+	child := prefix + r.indent
+	for _, c := range n.Children {
+		switch c.Type {
+		case NodeListItem:
+			marker := prefix + r.bullet
+			if r.markers[0] != "" {
+				own := r.markers[n.Mode&1]
+				marker = strings.Repeat(own, strings.Count(prefix, own)+1)
+				if r.chain {
+					marker = prefix + own
+				}
+				child = prefix + own
+			} else if n.Mode != 0 {
+				marker = prefix + fmt.Sprint(num, ".")
+				num++
+			}
+			lines = append(lines, marker+" "+r.inlines(c.Children))
+		case NodeList:
+			lines = append(lines, r.list(c, child)...)
+		}
+	}
+	return lines
+}
+
+func (r *markupDialect) inlines(nodes []*Node) string {
+	s := ""
+	for _, c := range nodes {
+		s += r.inline(c)
+	}
+	return s
+}
+
+func (r *markupDialect) inline(n *Node) string {
+	switch n.Type {
+	case NodeText, NodeStyle, NodeAbbr:
+		return r.escape(n.Text)
+	case NodeLineBreak:
+		if n.Hard && r.hardBreak != "" {
+			return r.hardBreak
+		} else if n.Hard {
+			return "\n"
+		}
+		return " "
+	case NodeCode:
+		return r.code(n.TextContent())
+	case NodeLink:
+		text := n.TextContent()
+		return r.link(n.URL, r.inlines(n.Children), text == n.URL || "mailto:"+text == n.URL)
+	case NodeImage:
+		if r.image != nil {
+			return r.image(n.URL, n.Alt)
+		}
+		return r.link(n.URL, r.escape(n.Alt), n.Alt == "")
+	case NodeCheckBox:
+		box := r.checkBox
+		if box[0] == "" {
+			box = [2]string{"☐ ", "☑ "}
+		}
+		if n.Checked {
+			return box[1]
+		}
+		return box[0]
+	case NodeRuby:
+		return r.escape(n.Text + "(" + n.Reading + ")")
+	}
+	if w, ok := r.wraps[n.Type]; ok {
+		return w[0] + r.inlines(n.Children) + w[1]
+	}
+	return r.inlines(n.Children)
+}
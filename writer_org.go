@@ -0,0 +1,172 @@
+package markdown
+
+import (
+	"io"
+	"regexp"
+	"strings"
+)
+
+// Org has no escape character. Markup is broken by a zero width space as the Org manual suggests.
+var orgMarkupRe = regexp.MustCompile(`(^|[\s('"{-])([*/_+=~])`)
+var orgLineStartRe = regexp.MustCompile(`^(\*+\s|#\+|#\s|[-+]\s|\d+[.)]\s|\||:\s|-{5,})`)
+var orgCodeLineRe = regexp.MustCompile(`(?m)^(\*|#\+)`)
+
+func escapeOrg(text string) string {
+	text = strings.Replace(text, "[[", "[\u200b[", -1)
+	return orgMarkupRe.ReplaceAllString(text, "$1\u200b$2")
+}
+
+var orgDialect = &markupDialect{
+	escape: escapeOrg,
+	lineStart: func(line string) string {
+		if orgLineStartRe.MatchString(line) {
+			return "\u200b" + line
+		}
+		return line
+	},
+	wraps: map[string][2]string{
+		NodeStrong:      {"*", "*"},
+		NodeEmphasis:    {"/", "/"},
+		NodeStrike:      {"+", "+"},
+		NodeInsert:      {"_", "_"},
+		NodeSuperscript: {"^{", "}"},
+		NodeSubscript:   {"_{", "}"},
+	},
+	code: func(text string) string {
+		if strings.Contains(text, "~") {
+			return "=" + text + "="
+		}
+		return "~" + text + "~"
+	},
+	heading: func(text string, level int) string {
+		return strings.Repeat("*", level) + " " + text
+	},
+	link: func(url, text string, auto bool) string {
+		if auto {
+			return url
+		}
+		return "[[" + url + "][" + strings.Replace(text, "]", "\u200b]", -1) + "]]"
+	},
+	image: func(url, alt string) string {
+		return "[[" + url + "]]"
+	},
+	codeBlock: func(lang, title, code string) string {
+		s := ""
+		if title != "" {
+			s += "#+CAPTION: " + title + "\n"
+		}
+		code = orgCodeLineRe.ReplaceAllString(code, ",$1")
+		if lang == "" {
+			return s + "#+BEGIN_EXAMPLE\n" + code + "#+END_EXAMPLE"
+		}
+		return s + "#+BEGIN_SRC " + lang + "\n" + code + "#+END_SRC"
+	},
+	quote: func(lines []string) string {
+		return "#+BEGIN_QUOTE\n" + strings.Join(lines, "\n") + "\n#+END_QUOTE"
+	},
+	table: func(d *markupDialect, n *Node) string {
+		caption, rows := tableRows(n)
+		if len(rows) == 0 {
+			return ""
+		}
+		var cells [][]string
+		var aligns []int
+		columns := 0
+		for _, row := range rows {
+			var line []string
+			for _, cell := range row {
+				line = append(line, strings.Replace(tableCellContent(d, cell, " "), "|", "\\vert{}", -1))
+				for i := 1; i < cellSpan(cell); i++ {
+					line = append(line, "")
+				}
+				for len(aligns) < len(line) {
+					aligns = append(aligns, cell.Align)
+				}
+			}
+			if len(line) > columns {
+				columns = len(line)
+			}
+			cells = append(cells, line)
+		}
+		cookies := make([]string, columns)
+		aligned := false
+		for i, a := range aligns {
+			if a != AlignDefault {
+				cookies[i] = []string{"", "<l>", "<r>", "<c>"}[a&3]
+				aligned = true
+			}
+		}
+		if aligned {
+			cells = append([][]string{cookies}, cells...)
+		}
+		widths := make([]int, columns)
+		for _, line := range cells {
+			for i, c := range line {
+				if stringWidth(c) > widths[i] {
+					widths[i] = stringWidth(c)
+				}
+			}
+		}
+		offset := 0
+		if aligned {
+			offset = 1
+		}
+		var lines []string
+		if caption != nil {
+			lines = append(lines, "#+CAPTION: "+d.inlines(caption.Children))
+		}
+		for i, line := range cells {
+			s := "|"
+			for j, width := range widths {
+				c := ""
+				if j < len(line) {
+					c = line[j]
+				}
+				align := AlignLeft
+				if i >= offset {
+					align = aligns[j]
+				}
+				s += " " + padText(c, width, align) + " |"
+			}
+			lines = append(lines, s)
+			if r := i - offset; r >= 0 && r+1 < len(rows) && orgHeader(rows[r]) && !orgHeader(rows[r+1]) {
+				lines = append(lines, orgRule(widths))
+			}
+		}
+		return strings.Join(lines, "\n")
+	},
+	hr:        "-----",
+	hardBreak: "\\\\\n",
+	bullet:    "-",
+	indent:    "  ",
+	checkBox:  [2]string{"[ ] ", "[X] "},
+}
+
+func orgHeader(row []*Node) bool {
+	return len(row) > 0 && row[0].Header
+}
+
+// orgRule returns a horizontal line of a table.
+func orgRule(widths []int) string {
+	var cols []string
+	for _, w := range widths {
+		cols = append(cols, strings.Repeat("-", w+2))
+	}
+	return "|" + strings.Join(cols, "+") + "|"
+}
+
+// OrgWriter : impl for DocWriter. It writes Org mode markup on Close.
+type OrgWriter struct {
+	*TreeWriter
+	writer io.Writer
+}
+
+func NewOrgWriter(writer io.Writer) *OrgWriter {
+	return &OrgWriter{TreeWriter: NewTreeWriter(), writer: writer}
+}
+
+func (w *OrgWriter) Close() {
+	w.TreeWriter.Close()
+	io.WriteString(w.writer, orgDialect.render(w.Root))
+	w.TreeWriter = NewTreeWriter()
+}
@@ -1,106 +1,58 @@
 package markdown
 
 import (
+	"fmt"
 	"io"
+	"strings"
 )
 
-// PlainWriter : impl for DocWriter
+// PlainWriter : impl for DocWriter. It writes readable plain text on Close.
+// Link destinations are collected as numbered footnotes at the end.
 type PlainWriter struct {
+	*TreeWriter
 	writer io.Writer
-}
-
-func NewPlainWriter(writer io.Writer) *PlainWriter {
-	return &PlainWriter{writer}
-}
-
-func (w *PlainWriter) Heading(text string, level int) int {
-	io.WriteString(w.writer, text+"\n")
-	return 0
-}
 
-func (w *PlainWriter) Paragraph() int {
-	io.WriteString(w.writer, "\n")
-	return 0
+	// Width wraps text and shrinks tables if > 0.
+	Width int
 }
 
-func (w *PlainWriter) Link(url string, title string, opt int) int {
-	io.WriteString(w.writer, url)
-	return 0
-}
-
-func (w *PlainWriter) Image(url string, title, alt string, opt int) int {
-	io.WriteString(w.writer, alt+"("+url+")")
-	return 0
-}
-
-func (w *PlainWriter) Hr() int {
-	return 0
-}
-
-func (w *PlainWriter) List(mode int) int {
-	io.WriteString(w.writer, "\n")
-	return 0
-}
-
-func (w *PlainWriter) ListItem() int {
-	return 0
-}
-
-func (w *PlainWriter) Table() int {
-	io.WriteString(w.writer, "\n")
-	return 0
-}
-
-func (w *PlainWriter) TableRow() int {
-	io.WriteString(w.writer, "\n")
-	return 0
-}
-
-func (w *PlainWriter) TableCell(flags int) int {
-	io.WriteString(w.writer, "\t")
-	return 0
-}
-
-func (w *PlainWriter) CheckBox(checked bool) int {
-	return 0
-}
-
-func (w *PlainWriter) Strike() int {
-	return 0
-}
-
-func (w *PlainWriter) Emphasis() int {
-	return 0
-}
-
-func (w *PlainWriter) Strong() int {
-	return 0
-}
-
-func (w *PlainWriter) Code() int {
-	return 0
-}
-
-func (w *PlainWriter) QuoteBlock() int {
-	return 0
-}
-
-func (w *PlainWriter) CodeBlock(lang string, title string) int {
-	io.WriteString(w.writer, "\n")
-	return 0
-}
-
-func (w *PlainWriter) WriteStyle(text string, className string, color string, flags int) {
-	w.Write(text)
-}
-
-func (w *PlainWriter) Write(text string) {
-	w.writer.Write([]byte(text))
-}
-
-func (w *PlainWriter) End(lv int) {
+func NewPlainWriter(writer io.Writer) *PlainWriter {
+	return &PlainWriter{TreeWriter: NewTreeWriter(), writer: writer, Width: 80}
 }
 
 func (w *PlainWriter) Close() {
-	w.End(0)
+	w.TreeWriter.Close()
+	var links []string
+	r := &textRenderer{
+		width:   w.Width,
+		bullets: []string{"-", "*", "+"},
+		quote:   "> ",
+		box:     strings.Split("+++++++++-|", ""),
+		code:    "    ",
+		rules:   []string{"=", "-"},
+		link: func(n *Node) string {
+			url := strings.TrimPrefix(n.URL, "mailto:")
+			if url == "" || n.Type == NodeLink && url == n.TextContent() {
+				return ""
+			}
+			for i, l := range links {
+				if l == n.URL {
+					return fmt.Sprintf("[%d]", i+1)
+				}
+			}
+			links = append(links, n.URL)
+			return fmt.Sprintf("[%d]", len(links))
+		},
+	}
+	lines := r.blocks(w.Root.Children, w.Width)
+	if len(links) > 0 {
+		lines = append(lines, "")
+		for i, l := range links {
+			lines = append(lines, fmt.Sprintf("[%d] %s", i+1, l))
+		}
+	}
+	if len(lines) > 0 {
+		io.WriteString(w.writer, strings.Join(lines, "\n")+"\n")
+	}
+	w.TreeWriter = NewTreeWriter()
 }
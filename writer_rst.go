@@ -0,0 +1,421 @@
+package markdown
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// RSTWriter : impl for DocWriter. It writes reStructuredText on Close.
+type RSTWriter struct {
+	*TreeWriter
+	writer io.Writer
+
+	// Adornments are characters of heading underlines for levels 1, 2, ...
+	// Level 1 headings also have an overline.
+	Adornments []string
+
+	roles  []string
+	images []*Node
+}
+
+func NewRSTWriter(writer io.Writer) *RSTWriter {
+	return &RSTWriter{TreeWriter: NewTreeWriter(), writer: writer, Adornments: []string{"=", "-", "~", "^"}}
+}
+
+// rstRoles are interpreted text roles which are not defined by docutils.
+var rstRoles = map[string]string{NodeStrike: "del", NodeMark: "mark", NodeInsert: "ins"}
+
+func (w *RSTWriter) Close() {
+	w.TreeWriter.Close()
+	blocks := w.blocks(w.Root.Children)
+	var defs []string
+	for i, img := range w.images {
+		def := fmt.Sprintf(".. |image%d| image:: %s", i+1, img.URL)
+		if img.Alt != "" {
+			def += "\n   :alt: " + img.Alt
+		}
+		defs = append(defs, def)
+	}
+	if len(defs) > 0 {
+		blocks = append(blocks, strings.Join(defs, "\n"))
+	}
+	if len(w.roles) > 0 {
+		var decls []string
+		for _, r := range w.roles {
+			decls = append(decls, ".. role:: "+r)
+		}
+		blocks = append([]string{strings.Join(decls, "\n")}, blocks...)
+	}
+	if len(blocks) > 0 {
+		io.WriteString(w.writer, strings.Join(blocks, "\n\n")+"\n")
+	}
+	w.TreeWriter = NewTreeWriter()
+	w.roles = nil
+	w.images = nil
+}
+
+func (w *RSTWriter) blocks(nodes []*Node) []string {
+	var blocks []string
+	for _, n := range nodes {
+		var lines []string
+		switch n.Type {
+		case NodeParagraph:
+			lines = w.lines(n.Children)
+		case NodeHeading:
+			text := escapeRST(n.Text)
+			adornment := w.Adornments[len(w.Adornments)-1]
+			if n.Level >= 1 && n.Level <= len(w.Adornments) {
+				adornment = w.Adornments[n.Level-1]
+			}
+			rule := strings.Repeat(adornment, stringWidth(text))
+			if id := n.Attrs.Get("id"); id != "" {
+				lines = append(lines, ".. _"+id+":", "")
+			}
+			if n.Level == 1 {
+				lines = append(lines, rule)
+			}
+			lines = append(lines, text, rule)
+		case NodeHr:
+			lines = []string{"----"}
+		case NodeList:
+			lines = w.list(n)
+		case NodeTable:
+			lines = w.table(n)
+		case NodeQuote:
+			for _, line := range w.lines(n.Children) {
+				lines = append(lines, strings.TrimRight("   "+line, " "))
+			}
+		case NodeCodeBlock:
+			if n.Lang != "" {
+				lines = append(lines, ".. code-block:: "+n.Lang)
+				if title := strings.TrimPrefix(n.Title, ":"); title != "" {
+					lines = append(lines, "   :caption: "+title)
+				}
+			} else {
+				lines = append(lines, "::")
+			}
+			lines = append(lines, "")
+			for _, line := range strings.Split(strings.TrimSuffix(n.TextContent(), "\n"), "\n") {
+				lines = append(lines, strings.TrimRight("   "+line, " "))
+			}
+		default:
+			if s := strings.TrimSpace(w.inline(n)); s != "" {
+				lines = []string{s}
+			}
+		}
+		if len(lines) > 0 {
+			blocks = append(blocks, strings.Join(lines, "\n"))
+		}
+	}
+	return blocks
+}
+
+// lines renders a paragraph. Hard line breaks make a line block.
+func (w *RSTWriter) lines(nodes []*Node) []string {
+	var lines []string
+	var cur []*Node
+	hard := false
+	for _, c := range nodes {
+		if c.Type == NodeLineBreak {
+			hard = hard || c.Hard
+			lines = append(lines, w.inlines(cur))
+			cur = nil
+			continue
+		}
+		cur = append(cur, c)
+	}
+	lines = append(lines, strings.TrimRight(w.inlines(cur), "\n"))
+	for i, line := range lines {
+		if hard {
+			lines[i] = strings.TrimRight("| "+line, " ")
+		} else {
+			lines[i] = escapeRSTLineStart(line)
+		}
+	}
+	return lines
+}
+
+func (w *RSTWriter) list(n *Node) []string {
+	var lines []string
+	num := 1
+	indent := "  "
+	for _, c := range n.Children {
+		switch c.Type {
+		case NodeListItem:
+			marker := "-"
+			if n.Mode != 0 {
+				marker = fmt.Sprint(num, ".")
+				num++
+			}
+			indent = strings.Repeat(" ", len(marker)+1)
+			if l := len(lines); l > 0 && lines[l-1] != "" && strings.HasPrefix(lines[l-1], " ") {
+				lines = append(lines, "")
+			}
+			lines = append(lines, marker+" "+escapeRSTLineStart(w.inlines(c.Children)))
+		case NodeList:
+			lines = append(lines, "")
+			for _, line := range w.list(c) {
+				lines = append(lines, strings.TrimRight(indent+line, " "))
+			}
+		}
+	}
+	return lines
+}
+
+func (w *RSTWriter) inlines(nodes []*Node) string {
+	s := ""
+	markup := false
+	for _, c := range nodes {
+		t := w.inline(c)
+		isMarkup := c.Type != NodeText && c.Type != NodeLineBreak && c.Type != NodeCheckBox && c.Type != NodeRuby
+		if isMarkup && endsWithWord(s) || markup && startsWithWord(t) {
+			s += "\\ "
+		}
+		s += t
+		markup = isMarkup
+	}
+	return s
+}
+
+// endsWithWord reports whether inline markup cannot start after s.
+func endsWithWord(s string) bool {
+	r, _ := utf8.DecodeLastRuneInString(s)
+	return s != "" && !unicode.IsSpace(r) && !strings.ContainsRune("-:/'\"<([{", r)
+}
+
+// startsWithWord reports whether inline markup cannot end before s.
+func startsWithWord(s string) bool {
+	r, _ := utf8.DecodeRuneInString(s)
+	return s != "" && !unicode.IsSpace(r) && !strings.ContainsRune("-.,:;!?/'\")]}>", r)
+}
+
+// inline renders an inline node. Inline markup cannot be nested in reStructuredText,
+// so the content of markup is rendered as text.
+func (w *RSTWriter) inline(n *Node) string {
+	text := escapeRST(n.TextContent())
+	switch n.Type {
+	case NodeText, NodeStyle:
+		return escapeRST(n.Text)
+	case NodeLineBreak:
+		return "\n"
+	case NodeEmphasis:
+		return "*" + text + "*"
+	case NodeStrong:
+		return "**" + text + "**"
+	case NodeCode:
+		return "``" + n.TextContent() + "``"
+	case NodeSuperscript:
+		return ":sup:`" + text + "`"
+	case NodeSubscript:
+		return ":sub:`" + text + "`"
+	case NodeStrike, NodeMark, NodeInsert:
+		role := rstRoles[n.Type]
+		found := false
+		for _, r := range w.roles {
+			found = found || r == role
+		}
+		if !found {
+			w.roles = append(w.roles, role)
+		}
+		return ":" + role + ":`" + text + "`"
+	case NodeAbbr:
+		return ":abbreviation:`" + text + "`"
+	case NodeLink:
+		if n.TextContent() == n.URL || "mailto:"+n.TextContent() == n.URL {
+			return text
+		}
+		return "`" + strings.NewReplacer("<", "\\<", "`", "\\`").Replace(text) + " <" + n.URL + ">`__"
+	case NodeImage:
+		w.images = append(w.images, n)
+		return fmt.Sprintf("|image%d|", len(w.images))
+	case NodeCheckBox:
+		if n.Checked {
+			return "[x] "
+		}
+		return "[ ] "
+	case NodeRuby:
+		return escapeRST(n.Text + "(" + n.Reading + ")")
+	}
+	return w.inlines(n.Children)
+}
+
+// table renders a simple table, or a grid table if cells span columns, have line breaks
+// or the first column has an empty cell.
+func (w *RSTWriter) table(n *Node) []string {
+	caption, rows := tableRows(n)
+	if len(rows) == 0 {
+		return nil
+	}
+	columns := 0
+	grid := false
+	contents := make([][][]string, len(rows))
+	for i, row := range rows {
+		c := 0
+		for _, cell := range row {
+			c += cellSpan(cell)
+			var lines []string
+			var cur []*Node
+			for _, child := range cell.Children {
+				if child.Type == NodeLineBreak {
+					lines = append(lines, w.inlines(cur))
+					cur = nil
+					grid = true
+				} else {
+					cur = append(cur, child)
+				}
+			}
+			contents[i] = append(contents[i], append(lines, w.inlines(cur)))
+			grid = grid || cellSpan(cell) > 1
+		}
+		if c > columns {
+			columns = c
+		}
+		// a blank first column makes a continuation line in a simple table.
+		grid = grid || len(row) == 0 || strings.TrimSpace(strings.Join(contents[i][0], "")) == ""
+	}
+	widths := make([]int, columns)
+	for i, row := range rows {
+		col := 0
+		for j, cell := range row {
+			if cellSpan(cell) == 1 {
+				for _, line := range contents[i][j] {
+					if stringWidth(line) > widths[col] {
+						widths[col] = stringWidth(line)
+					}
+				}
+			}
+			col += cellSpan(cell)
+		}
+	}
+	for i := range widths {
+		if widths[i] == 0 {
+			widths[i] = 1
+		}
+	}
+	cellWidth := func(col, span int) int {
+		w := 3 * (span - 1)
+		for c := col; c < col+span && c < columns; c++ {
+			w += widths[c]
+		}
+		return w
+	}
+	// widen the last column of spanning cells.
+	for i, row := range rows {
+		col := 0
+		for j, cell := range row {
+			for _, line := range contents[i][j] {
+				if d := stringWidth(line) - cellWidth(col, cellSpan(cell)); d > 0 {
+					widths[col+cellSpan(cell)-1] += d
+				}
+			}
+			col += cellSpan(cell)
+		}
+	}
+
+	var lines []string
+	if caption != nil {
+		lines = append(lines, ".. table:: "+w.inlines(caption.Children), "")
+	}
+	header := func(row []*Node) bool {
+		return len(row) > 0 && row[0].Header
+	}
+	border := func(c string) string {
+		s := "+"
+		if !grid {
+			s = ""
+		}
+		for i, width := range widths {
+			if grid {
+				s += strings.Repeat(c, width+2) + "+"
+			} else {
+				if i > 0 {
+					s += " "
+				}
+				s += strings.Repeat(c, width)
+			}
+		}
+		return s
+	}
+	if grid {
+		lines = append(lines, border("-"))
+	} else {
+		lines = append(lines, border("="))
+	}
+	for i, row := range rows {
+		height := 1
+		for _, c := range contents[i] {
+			if len(c) > height {
+				height = len(c)
+			}
+		}
+		for k := 0; k < height; k++ {
+			line := ""
+			if grid {
+				line = "|"
+			}
+			col := 0
+			for j, cell := range row {
+				text := ""
+				if k < len(contents[i][j]) {
+					text = contents[i][j][k]
+				}
+				width := cellWidth(col, cellSpan(cell))
+				if grid {
+					line += " " + padText(text, width, AlignLeft) + " |"
+				} else {
+					if j > 0 {
+						line += " "
+					}
+					line += padText(text, width, AlignLeft)
+				}
+				col += cellSpan(cell)
+			}
+			for ; col < columns; col++ {
+				if grid {
+					line += " " + strings.Repeat(" ", widths[col]) + " |"
+				} else {
+					line += " " + strings.Repeat(" ", widths[col])
+				}
+			}
+			lines = append(lines, strings.TrimRight(line, " "))
+		}
+		switch {
+		case grid && header(row) && i+1 < len(rows) && !header(rows[i+1]):
+			lines = append(lines, border("="))
+		case grid:
+			lines = append(lines, border("-"))
+		case header(row) && i+1 < len(rows) && !header(rows[i+1]):
+			lines = append(lines, border("="))
+		}
+	}
+	if !grid {
+		lines = append(lines, border("="))
+	}
+	if caption != nil {
+		for i := 2; i < len(lines); i++ {
+			lines[i] = strings.TrimRight("   "+lines[i], " ")
+		}
+	}
+	return lines
+}
+
+var rstReplacer = strings.NewReplacer("\\", "\\\\", "*", "\\*", "`", "\\`", "_", "\\_", "|", "\\|")
+
+// escapeRST escapes characters which can start inline markup.
+func escapeRST(text string) string {
+	return rstReplacer.Replace(text)
+}
+
+var rstLineStartRe = regexp.MustCompile(`^([-+•]\s|\d+[.)]\s|#\.\s|\.\.\s|::|[=~^"'#:.-]{4,}$)`)
+
+// escapeRSTLineStart escapes a line which can start a block.
+func escapeRSTLineStart(line string) string {
+	if rstLineStartRe.MatchString(line) {
+		return "\\" + line
+	}
+	return line
+}
@@ -0,0 +1,80 @@
+package markdown
+
+import (
+	"io"
+	"strings"
+)
+
+// DefaultTerminalStyles are SGR parameters used by TerminalWriter.
+// Keys are node types ("strong", "code", ...), "h1" to "h4" for headings,
+// classes of code tokens ("code_key", "code_str", ...) and other parts
+// ("url", "bullet", "quote", "border", "th", "caption", "hr", "ruby").
+var DefaultTerminalStyles = map[string]string{
+	"h1":           "1;4;36",
+	"h2":           "1;36",
+	"h3":           "1",
+	"h4":           "1",
+	"strong":       "1",
+	"emphasis":     "3",
+	"strike":       "9",
+	"code":         "33",
+	"mark":         "7",
+	"insert":       "4",
+	"link":         "4;34",
+	"url":          "2",
+	"image":        "35",
+	"ruby":         "2",
+	"bullet":       "36",
+	"quote":        "2",
+	"hr":           "2",
+	"border":       "2",
+	"th":           "1",
+	"caption":      "3",
+	"code_key":     "35",
+	"code_num":     "36",
+	"code_str":     "32",
+	"code_comment": "90",
+}
+
+// TerminalWriter : impl for DocWriter. It writes text decorated with ANSI escape sequences on Close.
+type TerminalWriter struct {
+	*TreeWriter
+	writer io.Writer
+
+	// Width wraps text and shrinks tables if > 0.
+	Width int
+	// Styles maps style names to SGR parameters. See DefaultTerminalStyles.
+	Styles map[string]string
+}
+
+func NewTerminalWriter(writer io.Writer) *TerminalWriter {
+	return &TerminalWriter{TreeWriter: NewTreeWriter(), writer: writer, Width: 80, Styles: DefaultTerminalStyles}
+}
+
+func (w *TerminalWriter) Close() {
+	w.TreeWriter.Close()
+	styles := w.Styles
+	if styles == nil {
+		styles = map[string]string{}
+	}
+	r := &textRenderer{
+		width:   w.Width,
+		styles:  styles,
+		bullets: []string{"•", "◦", "▪"},
+		quote:   "│ ",
+		box:     strings.Split("┌┬┐├┼┤└┴┘─│", ""),
+		code:    "  ",
+		link: func(n *Node) string {
+			url := strings.TrimPrefix(n.URL, "mailto:")
+			if url == "" || n.Type == NodeLink && url == n.TextContent() {
+				return ""
+			}
+			return " (" + n.URL + ")"
+		},
+	}
+	lines := r.blocks(w.Root.Children, w.Width)
+	if len(lines) > 0 {
+		io.WriteString(w.writer, strings.Join(lines, "\n")+"\n")
+	}
+	w.TreeWriter = NewTreeWriter()
+}
@@ -1,13 +1,30 @@
 package markdown
 
 import (
+	"bufio"
 	"bytes"
+	"io/ioutil"
+	"strings"
 	"testing"
 )
 
 func TestWriterInterface(t *testing.T) {
 	var _ DocWriter = NewHTMLWriter(nil)
 	var _ DocWriter = NewPlainWriter(nil)
+	var _ DocWriter = NewTreeWriter()
+	var _ DocWriter = NewMarkdownWriter(nil)
+	var _ DocWriter = NewTerminalWriter(nil)
+	var _ DocWriter = NewLaTeXWriter(nil)
+	var _ DocWriter = NewJSONWriter(nil)
+	var _ DocWriter = NewManWriter(nil)
+	var _ DocWriter = NewSlackWriter(nil)
+	var _ DocWriter = NewDiscordWriter(nil)
+	var _ DocWriter = NewTelegramWriter(nil)
+	var _ DocWriter = NewMediaWikiWriter(nil)
+	var _ DocWriter = NewJiraWriter(nil)
+	var _ DocWriter = NewAsciiDocWriter(nil)
+	var _ DocWriter = NewRSTWriter(nil)
+	var _ DocWriter = NewOrgWriter(nil)
 }
 
 type expectfun struct {
@@ -30,11 +47,29 @@ func TestHtmlWriter(t *testing.T) {
 	}
 }
 
+func TestHtmlInlineStyles(t *testing.T) {
+	styles := map[string]string{"p": "margin:0", "a": "color:blue;", "td": "padding:4pt", "code_key": "color:red", "pre": "background:#eee"}
+	tests := []expect{
+		expect{"a [b](c)", "<p style='margin:0'>a <a href='c' style='color:blue'>b</a></p>\n"},
+		expect{"|a|\n|-:|\n|1|", "<table>\n<thead>\n<tr><th style='text-align:right'>a</th></tr>\n</thead>\n<tbody>\n<tr><td style='padding:4pt;text-align:right'>1</td></tr>\n</tbody>\n</table>\n"},
+		expect{"```go\nreturn\n```", "<pre style='background:#eee'><code class='lang_go'><span class='code_key' style='color:red'>return</span>\n</code></pre>\n"},
+	}
+	for _, test := range tests {
+		var out bytes.Buffer
+		writer := NewHTMLWriter(&out)
+		writer.InlineStyles = styles
+		convertString(NewMarkdown(), test.input, writer)
+		if strings.TrimSpace(out.String()) != strings.TrimSpace(test.expected) {
+			t.Errorf("got %q\nwant %q", out.String(), test.expected)
+		}
+	}
+}
+
 func TestPlainWriter(t *testing.T) {
 	{
 		var out bytes.Buffer
 		writer := NewPlainWriter(&out)
-		expected := "Hello world!"
+		expected := "Hello world!\n"
 		writer.Write("Hello world!")
 		writer.Close()
 		actual := out.String()
@@ -43,25 +78,20 @@ func TestPlainWriter(t *testing.T) {
 		}
 	}
 	{
-		// TODO:
 		tests := []expectfun{
-			expectfun{func(w DocWriter) { w.Write("Hello") }, "Hello"},
-			expectfun{func(w DocWriter) { w.WriteStyle("Hello", "", "", 0) }, "Hello"},
-			expectfun{func(w DocWriter) { w.Strike() }, ""},
-			expectfun{func(w DocWriter) { w.Emphasis() }, ""},
-			expectfun{func(w DocWriter) { w.Strong() }, ""},
-			expectfun{func(w DocWriter) { w.Code() }, ""},
-			expectfun{func(w DocWriter) { w.Link("http://example.com", "test", 0) }, "http://example.com"},
-			expectfun{func(w DocWriter) { w.Image("http://example.com/a.png", "", "test", 0) }, "test(http://example.com/a.png)"},
-			expectfun{func(w DocWriter) { w.Heading("test", 1) }, "test\n"},
-			expectfun{func(w DocWriter) { w.Paragraph() }, "\n"},
-			expectfun{func(w DocWriter) { w.List(0) }, "\n"},
-			expectfun{func(w DocWriter) { w.ListItem() }, ""},
-			expectfun{func(w DocWriter) { w.Table() }, "\n"},
-			expectfun{func(w DocWriter) { w.TableRow() }, "\n"},
-			expectfun{func(w DocWriter) { w.TableCell(0) }, "\t"},
-			expectfun{func(w DocWriter) { w.CodeBlock("golang", "test") }, "\n"},
-			expectfun{func(w DocWriter) { w.Hr() }, ""},
+			expectfun{func(w DocWriter) { w.Write("Hello") }, "Hello\n"},
+			expectfun{func(w DocWriter) { w.WriteStyle("Hello", "", "", 0) }, "Hello\n"},
+			expectfun{func(w DocWriter) { w.Strong(); w.Write("Hello") }, "Hello\n"},
+			expectfun{func(w DocWriter) { w.Link("http://example.com", "test", 0); w.Write("test") }, "test[1]\n\n[1] http://example.com\n"},
+			expectfun{func(w DocWriter) { w.Image("http://example.com/a.png", "", "test", 0) }, "[test][1]\n\n[1] http://example.com/a.png\n"},
+			expectfun{func(w DocWriter) { w.Heading("test", 1) }, "test\n====\n"},
+			expectfun{func(w DocWriter) { w.Heading("テスト", 2) }, "テスト\n------\n"},
+			expectfun{func(w DocWriter) { w.Heading("test", 3) }, "test\n"},
+			expectfun{func(w DocWriter) { w.Hr() }, strings.Repeat("-", 80) + "\n"},
+			expectfun{func(w DocWriter) { w.Ruby("漢字", "かんじ") }, "漢字(かんじ)\n"},
+			expectfun{func(w DocWriter) { w.Abbr("API", "Application Programming Interface") }, "API\n"},
+			expectfun{func(w DocWriter) { w.Paragraph(); w.CheckBox(true); w.Write("done") }, "[x] done\n"},
+			expectfun{func(w DocWriter) { w.Paragraph(); w.Write("a"); w.LineBreak(true); w.Write("b") }, "a\nb\n"},
 		}
 
 		for _, test := range tests {
@@ -71,8 +101,438 @@ func TestPlainWriter(t *testing.T) {
 			writer.Close()
 			actual := out.String()
 			if actual != test.expected {
-				t.Errorf("got %v\nwant %v", actual, test.expected)
+				t.Errorf("got %q\nwant %q", actual, test.expected)
 			}
 		}
 	}
+
+	tests := []expect{
+		expect{"# a\n\nb\n\n## c", "a\n=\n\nb\n\nc\n-\n"},
+		expect{"aaa bbb ccc ddd\neee fff", "aaa bbb ccc ddd eee\nfff\n"},
+		expect{"* a\n  * b\n    * c\n\n1. d\n2. eeeeeeeee ffff", "- a\n  * b\n    + c\n\n1. d\n2. eeeeeeeee ffff\n"},
+		expect{"> aaa bbb ccc ddd eee", "> aaa bbb ccc ddd\n> eee\n"},
+		expect{"a | b\n:-|-:\n1|22", "+---+----+\n| a |  b |\n+---+----+\n| 1 | 22 |\n+---+----+\n"},
+		expect{"[a](http://a) [b](http://b) [c](http://a) <http://d>", "a[1] b[2] c[1]\nhttp://d\n\n[1] http://a\n[2] http://b\n"},
+		expect{"```go\nfunc main() {\n}\n```", "    func main() {\n    }\n"},
+	}
+	for _, test := range tests {
+		var out bytes.Buffer
+		writer := NewPlainWriter(&out)
+		writer.Width = 20
+		convertString(NewMarkdown(), test.input, writer)
+		if out.String() != test.expected {
+			t.Errorf("got %q\nwant %q", out.String(), test.expected)
+		}
+	}
+}
+
+func convertString(md *Markdown, input string, writer DocWriter) {
+	md.Convert(bufio.NewScanner(strings.NewReader(input)), writer)
+	writer.Close()
+}
+
+func TestMarkdownWriter(t *testing.T) {
+	tests := []expect{
+		expect{"##  hello\n#hello", "## hello\n\n#hello\n"},
+		expect{"* a\n* b\n  + c\n\n1. x\n1. y", "- a\n- b\n  - c\n\n1. x\n2. y\n"},
+		expect{"__a__ _b_ snake_case 1 * 2", "**a** *b* snake_case 1 \\* 2\n"},
+		expect{"a | b\n:-|-:\n1|22222", "| a   |     b |\n| :-- | ----: |\n| 1   | 22222 |\n"},
+		expect{"``` go {#x}\nfunc main() {}\n```", "```go {#x}\nfunc main() {}\n```\n"},
+		expect{"[a](<b c> 'd') <http://e>", "[a](<b c> \"d\") <http://e>\n"},
+		expect{"\\# a\n1\\. b\n\\- c", "\\# a\n1\\. b\n\\- c\n"},
+	}
+	for _, test := range tests {
+		var out bytes.Buffer
+		convertString(NewMarkdown(), test.input, NewMarkdownWriter(&out))
+		if out.String() != test.expected {
+			t.Errorf("got %q\nwant %q", out.String(), test.expected)
+		}
+	}
+
+	{
+		var out bytes.Buffer
+		writer := NewMarkdownWriter(&out)
+		writer.Width = 20
+		writer.BulletMarker = "*"
+		convertString(NewMarkdown(), "aaa bbb ccc\nddd `e f` ggg hhhhhhhhh - iii\n\n- item", writer)
+		expected := "aaa bbb ccc ddd\n`e f` ggg hhhhhhhhh\n\\- iii\n\n* item\n"
+		if out.String() != expected {
+			t.Errorf("got %q\nwant %q", out.String(), expected)
+		}
+	}
+	{
+		var out bytes.Buffer
+		writer := NewMarkdownWriter(&out)
+		writer.Width = 10
+		convertString(NewMarkdown(), "aaaa bbbb\ncccc dddd eeee", writer)
+		if expected := "aaaa bbbb\ncccc dddd\neeee\n"; out.String() != expected {
+			t.Errorf("got %q\nwant %q", out.String(), expected)
+		}
+	}
+}
+
+func TestTerminalWriter(t *testing.T) {
+	tests := []expect{
+		expect{"# hello", "hello\n"},
+		expect{"aaa bbb ccc ddd\neee [fff](http://example.com)", "aaa bbb ccc ddd eee\nfff\n(http://example.com)\n"},
+		expect{"日本語の文章を折り返します。", "日本語の文章を折り返\nします。\n"},
+		expect{"* a\n  * b\n\n1. c", "• a\n  ◦ b\n\n1. c\n"},
+		expect{"> a\n> b", "│ a b\n"},
+		expect{"a | 漢字\n:-|-:\n1|2", "┌───┬──────┐\n│ a │ 漢字 │\n├───┼──────┤\n│ 1 │    2 │\n└───┴──────┘\n"},
+		expect{"a | b\n-|-\naaaaaaaaa bbbbbbbbb|c", "┌───────────┬───┐\n│ a         │ b │\n├───────────┼───┤\n│ aaaaaaaaa │ c │\n│ bbbbbbbbb │   │\n└───────────┴───┘\n"},
+		expect{"```go\nfunc main() {\n}\n```", "  func main() {\n  }\n"},
+	}
+	for _, test := range tests {
+		var out bytes.Buffer
+		writer := NewTerminalWriter(&out)
+		writer.Width = 20
+		writer.Styles = nil
+		convertString(NewMarkdown(), test.input, writer)
+		if out.String() != test.expected {
+			t.Errorf("got %q\nwant %q", out.String(), test.expected)
+		}
+	}
+
+	styled := []expect{
+		expect{"# hello", "\x1b[1;4;36mhello\x1b[0m\n"},
+		expect{"**a b** `c`", "\x1b[1ma b\x1b[0m \x1b[33mc\x1b[0m\n"},
+		expect{"```go\nreturn \"s\"\n```", "  \x1b[35mreturn\x1b[0m \x1b[32m\"s\"\x1b[0m\n"},
+	}
+	for _, test := range styled {
+		var out bytes.Buffer
+		convertString(NewMarkdown(), test.input, NewTerminalWriter(&out))
+		if out.String() != test.expected {
+			t.Errorf("got %q\nwant %q", out.String(), test.expected)
+		}
+	}
+}
+
+func TestLaTeXWriter(t *testing.T) {
+	tests := []expect{
+		expect{"# 100% {sure} #1", "\\section{100\\% \\{sure\\} \\#1}\n"},
+		expect{"## a {#sec-a}\n\n#### b", "\\subsection{a}\\label{sec-a}\n\n\\paragraph{b}\n"},
+		expect{"a\\b ~c^ $d_e & **f** *g* `h`", "a\\textbackslash{}b \\textasciitilde{}c\\textasciicircum{} \\$d\\_e \\& \\textbf{f} \\emph{g} \\texttt{h}\n"},
+		expect{"* a\n  * b\n\n1. c", "\\begin{itemize}\n\\item a\n\\begin{itemize}\n\\item b\n\\end{itemize}\n\\end{itemize}\n\n\\begin{enumerate}\n\\item c\n\\end{enumerate}\n"},
+		expect{"a | b | c\n:-|-:|:-:\n1 | 2 | 3", "\\begin{tabular}{lrc}\n\\hline\n\\textbf{a} & \\textbf{b} & \\textbf{c} \\\\\n\\hline\n1 & 2 & 3 \\\\\n\\hline\n\\end{tabular}\n"},
+		expect{"[a](http://example.com/#x) <http://example.com/> ![b](c.png)", "\\href{http://example.com/\\#x}{a} \\url{http://example.com/} \\includegraphics{c.png}\n"},
+		expect{"```go:main.go\nfunc main() {}\n```", "\\begin{lstlisting}[caption={main.go}]\nfunc main() {}\n\\end{lstlisting}\n"},
+		expect{"```rb\nputs 1\n```", "\\begin{lstlisting}[language=Ruby]\nputs 1\n\\end{lstlisting}\n"},
+		expect{"a < b > c | d", "a \\textless{} b \\textgreater{} c \\textbar{} d\n"},
+		expect{"> a\n\n---", "\\begin{quote}\na\n\\end{quote}\n\n\\noindent\\rule{\\linewidth}{0.4pt}\n"},
+	}
+	for _, test := range tests {
+		var out bytes.Buffer
+		convertString(NewMarkdown(), test.input, NewLaTeXWriter(&out))
+		if out.String() != test.expected {
+			t.Errorf("got %q\nwant %q", out.String(), test.expected)
+		}
+	}
+}
+
+func TestJSONWriter(t *testing.T) {
+	tests := []expect{
+		expect{"# a", `{"type":"document","children":[{"type":"heading","text":"a","level":1}]}` + "\n"},
+		expect{"# a\nb\n\n- c", `{"type":"document","children":[{"type":"heading","text":"a","level":1},{"type":"paragraph","children":[{"type":"text","text":"b"}]},{"type":"list","children":[{"type":"listitem","children":[{"type":"text","text":"c"}]}]}]}` + "\n"},
+		expect{"a **b**", `{"type":"document","children":[{"type":"paragraph","children":[{"type":"text","text":"a "},{"type":"strong","children":[{"type":"text","text":"b"}]}]}]}` + "\n"},
+		expect{"|a|\n|-:|\n|1|", `{"type":"document","children":[{"type":"table","children":[{"type":"thead","children":[{"type":"tablerow","children":[{"type":"tablecell","align":2,"span":1,"header":true,"children":[{"type":"text","text":"a"}]}]}]},{"type":"tbody","children":[{"type":"tablerow","children":[{"type":"tablecell","align":2,"span":1,"children":[{"type":"text","text":"1"}]}]}]}]}]}` + "\n"},
+		expect{"```go\nreturn\n```", `{"type":"document","children":[{"type":"codeblock","lang":"go","children":[{"type":"style","text":"return","class":"code_key"},{"type":"text","text":"\n"}]}]}` + "\n"},
+	}
+	for _, test := range tests {
+		var out bytes.Buffer
+		convertString(NewMarkdown(), test.input, NewJSONWriter(&out))
+		if out.String() != test.expected {
+			t.Errorf("got %s\nwant %s", out.String(), test.expected)
+		}
+	}
+
+	sample, err := ioutil.ReadFile("examples/sample.md")
+	if err != nil {
+		t.Fatal(err)
+	}
+	inputs := []string{string(sample), "[a](b){#c .d}\n\n*[API]: Application Programming Interface\n\nThe API.", "- [x] a\n  - b"}
+	for _, input := range inputs {
+		var html1, html2, js bytes.Buffer
+		convertString(NewMarkdown(), input, NewHTMLWriter(&html1))
+		convertString(NewMarkdown(), input, NewJSONWriter(&js))
+		writer := NewHTMLWriter(&html2)
+		if err := ReadJSON(&js, writer); err != nil {
+			t.Fatal(err)
+		}
+		writer.Close()
+		if html1.String() != html2.String() {
+			t.Errorf("got %q\nwant %q", html2.String(), html1.String())
+		}
+	}
+
+	if err := ReadJSON(strings.NewReader(`{"type":"document","children":[{"type":"unknown"}]}`), NewTreeWriter()); err == nil {
+		t.Error("expected error for unknown node type")
+	}
+}
+
+func TestManWriter(t *testing.T) {
+	tests := []expect{
+		expect{"# foo(8)\n\n## NAME\n\nfoo\n.bar \\\\baz\n\n### a", ".TH \"FOO\" \"8\" \"\" \"\" \"\"\n.SH \"NAME\"\n.PP\nfoo\n\\&.bar \\ebaz\n.SS \"a\"\n"},
+		expect{"---\ntitle: bar\nsection: 5\n---\n\n# NAME", ".TH \"BAR\" \"5\" \"\" \"\" \"\"\n.SH \"NAME\"\n"},
+		expect{"---\ntitle: bar\nsource: https://example.com/\n---\n\n# NAME", ".TH \"BAR\" \"1\" \"\" \"https://example.com/\" \"\"\n.SH \"NAME\"\n"},
+		expect{"---\n\nNote: x\n\n---\n\n# foo(1)\n\n## NAME", ".TH \"FOO\" \"1\" \"\" \"\" \"\"\n.PP\n\\l'\\n(.lu'\n.PP\nNote: x\n.PP\n\\l'\\n(.lu'\n.SH \"NAME\"\n"},
+		expect{"# a\n\n- `-v`: verbose\n- b\n  1. c", ".TH \"A\" \"1\" \"\" \"\" \"\"\n.TP\n\\fB\\-v\\fP\nverbose\n.IP \\(bu 2\nb\n.RS\n.IP 1. 4\nc\n.RE\n"},
+		expect{"# a\n\n- `--flag` - description", ".TH \"A\" \"1\" \"\" \"\" \"\"\n.TP\n\\fB\\-\\-flag\\fP\ndescription\n"},
+		expect{"# a\n\n```sh\n.x *y*\n```", ".TH \"A\" \"1\" \"\" \"\" \"\"\n.PP\n.EX\n\\&.x *y*\n.EE\n"},
+		expect{"# a-b\n\nuse --flag\n\n```sh\nfoo -v\n```", ".TH \"A\\-B\" \"1\" \"\" \"\" \"\"\n.PP\nuse \\-\\-flag\n.PP\n.EX\nfoo \\-v\n.EE\n"},
+		expect{"# a\n\n|a|b|\n|-|-:|\n|1|2|", "'\\\" t\n.TH \"A\" \"1\" \"\" \"\" \"\"\n.TS\nallbox;\nlB rB\nl r.\na\tb\n1\t2\n.TE\n"},
+		expect{"# a\n\n*b* [c](http://d)", ".TH \"A\" \"1\" \"\" \"\" \"\"\n.PP\n\\fIb\\fP c <\\fIhttp://d\\fP>\n"},
+	}
+	for _, test := range tests {
+		var out bytes.Buffer
+		convertString(NewMarkdown(), test.input, NewManWriter(&out))
+		if out.String() != test.expected {
+			t.Errorf("got %q\nwant %q", out.String(), test.expected)
+		}
+	}
+}
+
+func TestSlackWriter(t *testing.T) {
+	tests := []expect{
+		expect{"# a *b*\n\n#### c", "*a \u2217b\u2217*\n\n*c*\n"},
+		expect{"***a*** *a **b** c* ~~d **e**~~", "_*a*_ _a *b* c_ ~d *e*~\n"},
+		expect{"1<2 & 3>2 `<c>`", "1&lt;2 &amp; 3&gt;2 `&lt;c&gt;`\n"},
+		expect{"[a|b](http://c/d|e) <http://f>", "<http://c/d%7Ce|a|b> <http://f>\n"},
+		expect{"![e](f.png)", "<f.png|e>\n"},
+		expect{"> a  \n> *b*", "> a\n> _b_\n"},
+		expect{"- i\n  1. j\n     - k\n- [x] l", "• i\n    1. j\n        • k\n• ☑ l\n"},
+		expect{"|l|m|\n|-|-:|\n|1|2 \\\n|3| |", "```\n+---+---+\n| l | m |\n+---+---+\n| 1 | 2 |\n| 3 |   |\n+---+---+\n```\n"},
+		expect{"```go\n<a> & b\n```\n\n---", "```\n&lt;a&gt; &amp; b\n```\n\n----\n"},
+	}
+	for _, test := range tests {
+		var out bytes.Buffer
+		convertString(NewMarkdown(), test.input, NewSlackWriter(&out))
+		if out.String() != test.expected {
+			t.Errorf("got %q\nwant %q", out.String(), test.expected)
+		}
+	}
+}
+
+func TestDiscordWriter(t *testing.T) {
+	tests := []expect{
+		expect{"# a\n\n#### b", "# a\n\n**b**\n"},
+		expect{"a \\*b\\* c_d ~e~ \\`x\\` |f| [g] <h> \\\\i", "a \\*b\\* c\\_d \\~e\\~ \\`x\\` \\|f\\| \\[g\\] \\<h\\> \\\\i\n"},
+		expect{"\\# a", "\\# a\n"},
+		expect{"\\> a", "\\> a\n"},
+		expect{"***a*** *a **b** c* ~~d **e**~~", "***a*** *a **b** c* ~~d **e**~~\n"},
+		expect{"``a`b`` `c`", "`` a`b `` `c`\n"},
+		expect{"[a|b](http://c) <http://f> ![e](f.png)", "[a\\|b](<http://c>) http://f [e](<f.png>)\n"},
+		expect{"> a  \n> *b*", "> a\n> *b*\n"},
+		expect{"1. b\n\n- c_d\n  - [ ] e", "1. b\n\n- c\\_d\n  - ☐ e\n"},
+		expect{"|l|m|\n|-|-:|\n|1|2 \\\n|3| |", "```\n+---+---+\n| l | m |\n+---+---+\n| 1 | 2 |\n| 3 |   |\n+---+---+\n```\n"},
+		expect{"```go\n<a> & b\n```", "```go\n<a> & b\n```\n"},
+	}
+	for _, test := range tests {
+		var out bytes.Buffer
+		convertString(NewMarkdown(), test.input, NewDiscordWriter(&out))
+		if out.String() != test.expected {
+			t.Errorf("got %q\nwant %q", out.String(), test.expected)
+		}
+	}
+}
+
+func TestTelegramWriter(t *testing.T) {
+	tests := []expect{
+		expect{"# a\n\n#### b", "<b>a</b>\n\n<b>b</b>\n"},
+		expect{"1<2 & \"3\" `<c>` \\*d\\*", "1&lt;2 &amp; &quot;3&quot; <code>&lt;c&gt;</code> *d*\n"},
+		expect{"***a*** *a **b** c* ~~d **e**~~", "<i><b>a</b></i> <i>a <b>b</b> c</i> <s>d <b>e</b></s>\n"},
+		expect{"[a](http://b/?c=1&d=\"2\") <http://f>", "<a href=\"http://b/?c=1&amp;d=&quot;2&quot;\">a</a> <a href=\"http://f\">http://f</a>\n"},
+		expect{"> a  \n> *b*", "<blockquote>a\n<i>b</i></blockquote>\n"},
+		expect{"- i\n  1. j\n     - k", "• i\n  1. j\n    • k\n"},
+		expect{"|l|m|\n|-|-:|\n|1|2 \\\n|3| |", "<pre><code>+---+---+\n| l | m |\n+---+---+\n| 1 | 2 |\n| 3 |   |\n+---+---+\n</code></pre>\n"},
+		expect{"```go\n<a> & b\n```", "<pre><code class=\"language-go\">&lt;a&gt; &amp; b\n</code></pre>\n"},
+	}
+	for _, test := range tests {
+		var out bytes.Buffer
+		convertString(NewMarkdown(), test.input, NewTelegramWriter(&out))
+		if out.String() != test.expected {
+			t.Errorf("got %q\nwant %q", out.String(), test.expected)
+		}
+	}
+}
+
+func TestMediaWikiWriter(t *testing.T) {
+	tests := []expect{
+		expect{"# a\nb\n\n#### c\n\n---", "= a =\n\nb\n\n==== c ====\n\n----\n"},
+		expect{"'''a''' [b] {c} |d| <e> &f x__y__", "&#39;''a&#39;'' &#91;b&#93; &#123;c&#125; &#124;d&#124; &lt;e&gt; &amp;f x&#95;_y&#95;_\n"},
+		expect{"\\* a", "<nowiki/>* a\n"},
+		expect{"; a", "<nowiki/>; a\n"},
+		expect{"***a*** *a **b** c* ~~d~~", "'''''a''''' ''a '''b''' c'' <s>d</s>\n"},
+		expect{"`<c>` `{d}`", "<code>&lt;c&gt;</code> <code>&#123;d&#125;</code>\n"},
+		expect{"[a|b](http://c) <http://f> ![e](f.png)", "[http://c a&#124;b] http://f [[File:f.png|e]]\n"},
+		expect{"a  \nb", "a<br />\nb\n"},
+		expect{"> a  \n> *b*", "<blockquote>a<br />\n''b''</blockquote>\n"},
+		expect{"- i\n  1. j\n     - k", "* i\n*# j\n*#* k\n"},
+		expect{"|l|m|\n|-|-:|\n|1|2 \\\n|3| |", "{| class=\"wikitable\"\n|-\n! l !! style=\"text-align:right\" | m\n|-\n| 1<br />3 || style=\"text-align:right\" | 2\n|}\n"},
+		expect{"```go:main.go\n<a> & b\n```", "<syntaxhighlight lang=\"go\">\n<a> & b\n</syntaxhighlight>\n"},
+	}
+	for _, test := range tests {
+		var out bytes.Buffer
+		convertString(NewMarkdown(), test.input, NewMediaWikiWriter(&out))
+		if out.String() != test.expected {
+			t.Errorf("got %q\nwant %q", out.String(), test.expected)
+		}
+	}
+}
+
+func TestJiraWriter(t *testing.T) {
+	tests := []expect{
+		expect{"# a\n\n#### b", "h1. a\n\nh4. b\n"},
+		expect{"a\\*b\\* c_d -e- \\+f\\+ ^g^ ~h~ {i} \\[j\\] |k| !l!", "a\\*b\\* c\\_d \\-e\\- \\+f\\+ \\^g\\^ \\~h\\~ \\{i\\} \\[j\\] \\|k\\| \\!l\\!\n"},
+		expect{"C:\\path\\to", "C:&#92;path&#92;to\n"},
+		expect{"h1. a", "\\h1. a\n"},
+		expect{"***a*** *a **b** c* ~~d~~", "_*a*_ _a *b* c_ -d-\n"},
+		expect{"`a*b` `{c}`", "{{a\\*b}} {{\\{c\\}}}\n"},
+		expect{"[a|b](http://c) <http://f> ![e](f.png)", "[a\\|b|http://c] [http://f] !f.png|alt=e!\n"},
+		expect{"a  \nb", "a\\\\\nb\n"},
+		expect{"> a  \n> *b*", "{quote}\na\\\\\n_b_\n{quote}\n"},
+		expect{"- i\n  1. j\n     - k", "* i\n*# j\n*#* k\n"},
+		expect{"|l|m|\n|-|-:|\n|1|2 \\\n|3| |", "||l||m||\n|1\\\\3|2|\n"},
+		expect{"```go:main.go\nx := y\n```", "{code:language=go|title=main.go}\nx := y\n{code}\n"},
+	}
+	for _, test := range tests {
+		var out bytes.Buffer
+		convertString(NewMarkdown(), test.input, NewJiraWriter(&out))
+		if out.String() != test.expected {
+			t.Errorf("got %q\nwant %q", out.String(), test.expected)
+		}
+	}
+}
+
+func TestAsciiDocWriter(t *testing.T) {
+	tests := []expect{
+		expect{"# a\n\n#### b", "== a\n\n===== b\n"},
+		expect{"snake_case a+b [x] C:\\path", "snake_case a+b [x] C:\\path\n"},
+		expect{"a \\*b\\* \\_c\\_ \\`d\\` \\+f\\+ ^g^ ~h~", "a {asterisk}b{asterisk} {underscore}c{underscore} {backtick}d{backtick} {plus}f{plus} {caret}g^ {tilde}h~\n"},
+		expect{"a\\*\\*b\\*\\* x\\_\\_y\\_\\_", "a{asterisk}{asterisk}b{asterisk}{asterisk} x{underscore}{underscore}y{underscore}{underscore}\n"},
+		expect{"{attr} [[id]] <<ref>>", "\\{attr} pass:c[[[]id]] pass:c[<<]ref>>\n"},
+		expect{"\\- a", "{empty}- a\n"},
+		expect{"***a*** *a **b** c* ~~d~~", "_*a*_ _a *b* c_ [line-through]#d#\n"},
+		expect{"`a+b` `a~b`", "`+a+b+` `+a~b+`\n"},
+		expect{"[a|b](http://c) <http://f> ![e](f.png)", "link:++http://c++[a|b] http://f image:++f.png++[e]\n"},
+		expect{"> a  \n> *b*", "____\na +\n_b_\n____\n"},
+		expect{"- i\n  1. j\n     - k", "* i\n. j\n** k\n"},
+		expect{"|l|m|\n|-|-:|\n|1|2 \\\n|3| |", "[cols=\"<,>\",options=\"header\"]\n|===\n|l |m\n|1 +\n3 |2\n|===\n"},
+		expect{"```go:main.go\nx := y\n```", ".main.go\n[source,go]\n----\nx := y\n----\n"},
+	}
+	for _, test := range tests {
+		var out bytes.Buffer
+		convertString(NewMarkdown(), test.input, NewAsciiDocWriter(&out))
+		if out.String() != test.expected {
+			t.Errorf("got %q\nwant %q", out.String(), test.expected)
+		}
+	}
+}
+
+func TestRSTWriter(t *testing.T) {
+	tests := []expect{
+		expect{"# Title\n\n#### b", "=====\nTitle\n=====\n\nb\n^\n"},
+		expect{"# 表題", "====\n表題\n====\n"},
+		expect{"a \\*b\\* \\`c\\` \\_d\\_ |e| \\\\f", "a \\*b\\* \\`c\\` \\_d\\_ \\|e\\| \\\\f\n"},
+		expect{"c_d e_ f `g`_", "c\\_d e\\_ f ``g``\\ \\_\n"},
+		expect{"\\- a", "\\- a\n"},
+		expect{"**a**b ~~g~~|h", ".. role:: del\n\n**a**\\ b :del:`g`\\ \\|h\n"},
+		expect{"***a*** *a **b** c*", "*a* *a b c*\n"},
+		expect{"[a|b](http://c) <http://f>", "`a\\|b <http://c>`__ http://f\n"},
+		expect{"![e](f.png)", "|image1|\n\n.. |image1| image:: f.png\n   :alt: e\n"},
+		expect{"a  \nb", "| a\n| b\n"},
+		expect{"> a  \n> *b*", "   | a\n   | *b*\n"},
+		expect{"- i\n  1. j\n     - k", "- i\n\n  1. j\n\n     - k\n"},
+		expect{"|a|b|\n|-|-|\n|1|2|\n|漢字|y|", "==== =\na    b\n==== =\n1    2\n漢字 y\n==== =\n"},
+		expect{"|l|m|\n|-|-:|\n|1|2 \\\n|3| |", "+---+---+\n| l | m |\n+===+===+\n| 1 | 2 |\n| 3 |   |\n+---+---+\n"},
+		expect{"| |x|\n|-|-|\n| |y|", "+---+---+\n|   | x |\n+===+===+\n|   | y |\n+---+---+\n"},
+		expect{"```go:main.go\nx := y\n```", ".. code-block:: go\n   :caption: main.go\n\n   x := y\n"},
+	}
+	for _, test := range tests {
+		var out bytes.Buffer
+		convertString(NewMarkdown(), test.input, NewRSTWriter(&out))
+		if out.String() != test.expected {
+			t.Errorf("got %q\nwant %q", out.String(), test.expected)
+		}
+	}
+}
+
+func TestOrgWriter(t *testing.T) {
+	tests := []expect{
+		expect{"# a\nb\n\n#### c\n\n---", "* a\n\nb\n\n**** c\n\n-----\n"},
+		expect{"\\*a\\* /b/ \\_c\\_ +d+ =e= \\~f\\~ [[g]]", "\u200b*a* \u200b/b/ \u200b_c_ \u200b+d+ \u200b=e= \u200b~f~ [\u200b[g]]\n"},
+		expect{"a\\*b\\* c_d", "a*b* c_d\n"},
+		expect{"\\* a", "\u200b* a\n"},
+		expect{"\\#+b", "\u200b#+b\n"},
+		expect{"\\| c", "\u200b| c\n"},
+		expect{"***a*** *a **b** c* ~~d~~", "/*a*/ /a *b* c/ +d+\n"},
+		expect{"`a` `a~b`", "~a~ =a~b=\n"},
+		expect{"[a|b](http://c) <http://f> ![e](f.png)", "[[http://c][a|b]] http://f [[f.png]]\n"},
+		expect{"a  \nb", "a\\\\\nb\n"},
+		expect{"> a  \n> *b*", "#+BEGIN_QUOTE\na\\\\\n/b/\n#+END_QUOTE\n"},
+		expect{"- i\n  1. j\n     - k\n- [x] a *b", "- i\n  1. j\n    - k\n- [X] a \u200b*b\n"},
+		expect{"|j|k|\n|-|-:|\n|1|22|", "|   | <r> |\n| j |   k |\n|---+-----|\n| 1 |  22 |\n"},
+		expect{"|l|m|\n|-|-|\n|1|2 \\\n|3| |", "| l   | m |\n|-----+---|\n| 1 3 | 2 |\n"},
+		expect{"```go:main.go\nx := y\n```", "#+CAPTION: main.go\n#+BEGIN_SRC go\nx := y\n#+END_SRC\n"},
+		expect{"```\n* c\n```", "#+BEGIN_EXAMPLE\n,* c\n#+END_EXAMPLE\n"},
+	}
+	for _, test := range tests {
+		var out bytes.Buffer
+		convertString(NewMarkdown(), test.input, NewOrgWriter(&out))
+		if out.String() != test.expected {
+			t.Errorf("got %q\nwant %q", out.String(), test.expected)
+		}
+	}
+}
+
+func TestMarkdownRoundTrip(t *testing.T) {
+	sample, err := ioutil.ReadFile("examples/sample.md")
+	if err != nil {
+		t.Fatal(err)
+	}
+	inputs := []string{string(sample)}
+	for _, test := range convertTests {
+		inputs = append(inputs, test.input)
+	}
+	inputs = append(inputs,
+		"|a|b|\n|-|-|\n|1|2| \\\n|3| |\n[cap]",
+		"> quote  \n> **aaa** [link](http://example.com/(a) \"t\")",
+		"*[API]: Application Programming Interface\n\nThe API.",
+		"*a*_b_ ***c*** ==d== ^e^ ~f~ {漢字|かんじ}",
+	)
+
+	md := NewMarkdown()
+	md.AddInline(MarkMatcher, InsertMatcher, SuperscriptMatcher, SubscriptMatcher, RubyMatcher)
+	for _, input := range inputs {
+		var html1, html2, md1, md2 bytes.Buffer
+		convertString(md, input, NewHTMLWriter(&html1))
+		convertString(md, input, NewMarkdownWriter(&md1))
+		convertString(md, md1.String(), NewHTMLWriter(&html2))
+		convertString(md, md1.String(), NewMarkdownWriter(&md2))
+		if strings.TrimSpace(html1.String()) != strings.TrimSpace(html2.String()) {
+			t.Errorf("input %q\nmarkdown %q\ngot %q\nwant %q", input, md1.String(), html2.String(), html1.String())
+		}
+		if md1.String() != md2.String() {
+			t.Errorf("input %q\ngot %q\nwant %q", input, md2.String(), md1.String())
+		}
+	}
+
+	// wrapped output is stable and keeps the content.
+	inputs = append(inputs, "aaaa bbbb\ncccc dddd eeee", "a *b c*\nd [e f](g) h\ni")
+	for _, input := range inputs {
+		var html1, html2, md1, md2 bytes.Buffer
+		writer := NewMarkdownWriter(&md1)
+		writer.Width = 10
+		convertString(md, input, writer)
+		writer = NewMarkdownWriter(&md2)
+		writer.Width = 10
+		convertString(md, md1.String(), writer)
+		convertString(md, input, NewHTMLWriter(&html1))
+		convertString(md, md1.String(), NewHTMLWriter(&html2))
+		if strings.Join(strings.Fields(html1.String()), " ") != strings.Join(strings.Fields(html2.String()), " ") {
+			t.Errorf("input %q\nmarkdown %q\ngot %q\nwant %q", input, md1.String(), html2.String(), html1.String())
+		}
+		if md1.String() != md2.String() {
+			t.Errorf("input %q\ngot %q\nwant %q", input, md2.String(), md1.String())
+		}
+	}
 }
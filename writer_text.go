@@ -0,0 +1,463 @@
+package markdown
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// textRenderer lays out a document tree as lines of text for fixed-width output.
+type textRenderer struct {
+	width   int               // wrapping width. 0 for no wrapping
+	styles  map[string]string // SGR parameters by style name. nil for no escape sequences
+	bullets []string          // markers of unordered list items by depth
+	quote   string            // prefix of quoted lines
+	box     []string          // table borders: ┌ ┬ ┐ ├ ┼ ┤ └ ┴ ┘ ─ │
+	code    string            // prefix of code block lines
+	rules   []string          // characters to underline headings by level
+	link    func(n *Node) string
+}
+
+// textUnit is a word or a CJK character, which is not split by wrapping.
+type textUnit struct {
+	text    string
+	width   int
+	space   bool // preceded by a space
+	brk     bool // line can break before the unit
+	newline bool // hard line break
+}
+
+// sgr returns text decorated with the styles.
+func (r *textRenderer) sgr(text string, styles ...string) string {
+	if r.styles == nil || text == "" {
+		return text
+	}
+	var params []string
+	for _, s := range styles {
+		if p := r.styles[s]; p != "" {
+			params = append(params, p)
+		} else if strings.HasPrefix(s, "#") && len(s) == 7 {
+			var red, green, blue int
+			if _, err := fmt.Sscanf(s, "#%02x%02x%02x", &red, &green, &blue); err == nil {
+				params = append(params, fmt.Sprintf("38;2;%d;%d;%d", red, green, blue))
+			}
+		}
+	}
+	if len(params) == 0 {
+		return text
+	}
+	return "\x1b[" + strings.Join(params, ";") + "m" + text + "\x1b[0m"
+}
+
+// textWidth returns the number of columns of text without escape sequences.
+func textWidth(text string) int {
+	w := 0
+	for pos := 0; pos < len(text); {
+		if text[pos] == 0x1b {
+			if p := strings.IndexByte(text[pos:], 'm'); p > 0 {
+				pos += p + 1
+				continue
+			}
+		}
+		r, l := utf8.DecodeRuneInString(text[pos:])
+		w += runeWidth(r)
+		pos += l
+	}
+	return w
+}
+
+// appendText splits text into units. Spaces are collapsed into the space flag
+// of the following unit, and lines can break between wide characters.
+func (r *textRenderer) appendText(units []textUnit, text string, styles []string) []textUnit {
+	add := func(s string, space, brk bool) {
+		if l := len(units); l > 0 && units[l-1].text == "" && units[l-1].space {
+			units = units[:l-1]
+			space = true
+		}
+		units = append(units, textUnit{text: r.sgr(s, styles...), width: textWidth(s), space: space, brk: brk || space})
+	}
+	text = strings.NewReplacer("\n", " ", "\t", " ").Replace(text)
+	for i, word := range strings.Split(text, " ") {
+		space := i > 0
+		brk := false
+		start := 0
+		for pos, c := range word {
+			if isWide(c) {
+				if pos > start {
+					add(word[start:pos], space, brk)
+					space = false
+				}
+				l := utf8.RuneLen(c)
+				add(word[pos:pos+l], space, true)
+				space, brk = false, true
+				start = pos + l
+			}
+		}
+		if start < len(word) || space && word == "" {
+			add(word[start:], space, brk)
+		}
+	}
+	return units
+}
+
+// units converts inline nodes to units.
+func (r *textRenderer) units(nodes []*Node, styles []string, units []textUnit) []textUnit {
+	for _, n := range nodes {
+		switch n.Type {
+		case NodeText, NodeAbbr:
+			units = r.appendText(units, n.Text, styles)
+		case NodeStyle:
+			units = r.appendText(units, n.Text, append(styles[:len(styles):len(styles)], n.Class, n.Color))
+		case NodeLineBreak:
+			if n.Hard {
+				units = append(units, textUnit{newline: true})
+			} else if len(units) > 0 {
+				units = r.appendText(units, " ", styles)
+			}
+		case NodeLink:
+			units = r.units(n.Children, append(styles[:len(styles):len(styles)], "link"), units)
+			if r.link != nil {
+				units = r.appendText(units, r.link(n), append(styles[:len(styles):len(styles)], "url"))
+			}
+		case NodeImage:
+			units = r.appendText(units, "["+n.Alt+"]", append(styles[:len(styles):len(styles)], "image"))
+			if r.link != nil {
+				units = r.appendText(units, r.link(n), append(styles[:len(styles):len(styles)], "url"))
+			}
+		case NodeCheckBox:
+			box := "[ ] "
+			if n.Checked {
+				box = "[x] "
+			}
+			units = r.appendText(units, box, styles)
+		case NodeRuby:
+			units = r.appendText(units, n.Text, styles)
+			units = r.appendText(units, "("+n.Reading+")", append(styles[:len(styles):len(styles)], "ruby"))
+		case NodeSuperscript:
+			units = r.appendText(units, "^", styles)
+			units = r.units(n.Children, styles, units)
+		case NodeSubscript:
+			units = r.appendText(units, "_", styles)
+			units = r.units(n.Children, styles, units)
+		default:
+			units = r.units(n.Children, append(styles[:len(styles):len(styles)], n.Type), units)
+		}
+	}
+	return units
+}
+
+// wrap joins units into lines not wider than width.
+func wrap(units []textUnit, width int) []string {
+	var lines []string
+	line := ""
+	lw := 0
+	for _, u := range units {
+		if u.newline {
+			lines = append(lines, line)
+			line, lw = "", 0
+			continue
+		}
+		if u.text == "" {
+			continue
+		}
+		sep := ""
+		if u.space && lw > 0 {
+			sep = " "
+		}
+		if width > 0 && lw > 0 && u.brk && lw+len(sep)+u.width > width {
+			lines = append(lines, line)
+			line, lw = u.text, u.width
+			continue
+		}
+		line = joinStyled(line, sep, u.text)
+		lw += len(sep) + u.width
+	}
+	return append(lines, line)
+}
+
+const sgrReset = "\x1b[0m"
+
+// joinStyled appends sep and text to line. If the last styled text of line
+// and text have the same style, they are merged into one sequence.
+func joinStyled(line, sep, text string) string {
+	if strings.HasPrefix(text, "\x1b[") && strings.HasSuffix(line, sgrReset) {
+		open := text[:strings.IndexByte(text, 'm')+1]
+		body := line[:len(line)-len(sgrReset)]
+		if strings.HasPrefix(body[strings.LastIndex(body, "\x1b["):], open) {
+			return body + sep + text[len(open):]
+		}
+	}
+	return line + sep + text
+}
+
+// blocks renders block nodes as lines. Blocks are separated by blank lines.
+func (r *textRenderer) blocks(nodes []*Node, width int) []string {
+	var lines []string
+	for _, n := range nodes {
+		block := r.block(n, width)
+		if len(block) == 0 {
+			continue
+		}
+		if len(lines) > 0 {
+			lines = append(lines, "")
+		}
+		lines = append(lines, block...)
+	}
+	return lines
+}
+
+func (r *textRenderer) block(n *Node, width int) []string {
+	switch n.Type {
+	case NodeParagraph:
+		return wrap(r.units(n.Children, nil, nil), width)
+	case NodeHeading:
+		style := fmt.Sprint("h", n.Level)
+		lines := wrap(r.appendText(nil, n.Text, []string{style}), width)
+		if n.Level >= 1 && n.Level <= len(r.rules) {
+			w := 0
+			for _, line := range lines {
+				if textWidth(line) > w {
+					w = textWidth(line)
+				}
+			}
+			lines = append(lines, strings.Repeat(r.rules[n.Level-1], w))
+		}
+		return lines
+	case NodeHr:
+		w := width
+		if w <= 0 {
+			w = 40
+		}
+		return []string{r.sgr(strings.Repeat(r.box[9], w), "hr")}
+	case NodeList:
+		return r.list(n, width, 0)
+	case NodeTable:
+		return r.table(n, width)
+	case NodeQuote:
+		prefix := r.sgr(r.quote, "quote")
+		inner := width - textWidth(r.quote)
+		if width <= 0 {
+			inner = 0
+		}
+		var lines []string
+		for _, line := range wrap(r.units(n.Children, nil, nil), inner) {
+			lines = append(lines, prefix+line)
+		}
+		return lines
+	case NodeCodeBlock:
+		var line string
+		var lines []string
+		for _, c := range n.Children {
+			parts := strings.Split(c.Text, "\n")
+			for i, part := range parts {
+				if i > 0 {
+					lines = append(lines, strings.TrimRight(r.code+line, " "))
+					line = ""
+				}
+				if c.Type == NodeStyle {
+					line += r.sgr(part, c.Class, c.Color)
+				} else {
+					line += r.sgr(part, "codeblock")
+				}
+			}
+		}
+		if line != "" {
+			lines = append(lines, r.code+line)
+		}
+		return lines
+	case NodeText:
+		if strings.TrimSpace(n.Text) == "" {
+			return nil
+		}
+	}
+	return wrap(r.units([]*Node{n}, nil, nil), width)
+}
+
+func (r *textRenderer) list(n *Node, width int, depth int) []string {
+	var lines []string
+	num := 1
+	indent := "  "
+	for _, c := range n.Children {
+		switch c.Type {
+		case NodeListItem:
+			marker := r.bullets[depth%len(r.bullets)]
+			if n.Mode != 0 {
+				marker = fmt.Sprint(num, ".")
+				num++
+			}
+			indent = strings.Repeat(" ", textWidth(marker)+1)
+			inner := width - len(indent)
+			if width <= 0 {
+				inner = 0
+			}
+			for i, line := range wrap(r.units(c.Children, nil, nil), inner) {
+				if i == 0 {
+					lines = append(lines, r.sgr(marker, "bullet")+" "+line)
+				} else {
+					lines = append(lines, indent+line)
+				}
+			}
+		case NodeList:
+			inner := width - len(indent)
+			if width <= 0 {
+				inner = 0
+			}
+			for _, line := range r.list(c, inner, depth+1) {
+				lines = append(lines, indent+line)
+			}
+		}
+	}
+	return lines
+}
+
+// table renders a table with borders. Columns are shrunk to fit in width.
+func (r *textRenderer) table(n *Node, width int) []string {
+	var caption []textUnit
+	captionNode, rows := tableRows(n)
+	if captionNode != nil {
+		caption = r.units(captionNode.Children, []string{"caption"}, nil)
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+
+	columns := 0
+	for _, row := range rows {
+		c := 0
+		for _, cell := range row {
+			c += cellSpan(cell)
+		}
+		if c > columns {
+			columns = c
+		}
+	}
+	units := make([][][]textUnit, len(rows))
+	widths := make([]int, columns)
+	for i, row := range rows {
+		col := 0
+		for _, cell := range row {
+			var styles []string
+			if cell.Header {
+				styles = []string{"th"}
+			}
+			u := r.units(cell.Children, styles, nil)
+			units[i] = append(units[i], u)
+			if cellSpan(cell) == 1 {
+				for _, line := range wrap(u, 0) {
+					if w := textWidth(line); w > widths[col] {
+						widths[col] = w
+					}
+				}
+			}
+			col += cellSpan(cell)
+		}
+	}
+	for width > 0 {
+		total := 1
+		widest := 0
+		for i, w := range widths {
+			total += w + 3
+			if w > widths[widest] {
+				widest = i
+			}
+		}
+		if total <= width || widths[widest] <= 4 {
+			break
+		}
+		widths[widest]--
+	}
+	// fit shrunk columns to the wrapped text.
+	fitted := make([]int, columns)
+	for i, row := range rows {
+		col := 0
+		for j, cell := range row {
+			if cellSpan(cell) == 1 {
+				for _, line := range wrap(units[i][j], widths[col]) {
+					if w := textWidth(line); w > fitted[col] {
+						fitted[col] = w
+					}
+				}
+			}
+			col += cellSpan(cell)
+		}
+	}
+	widths = fitted
+
+	border := func(left, mid, right string) string {
+		s := left
+		for i, w := range widths {
+			if i > 0 {
+				s += mid
+			}
+			s += strings.Repeat(r.box[9], w+2)
+		}
+		return r.sgr(s+right, "border")
+	}
+	vertical := r.sgr(r.box[10], "border")
+
+	var lines []string
+	if len(caption) > 0 {
+		lines = append(lines, wrap(caption, width)...)
+	}
+	lines = append(lines, border(r.box[0], r.box[1], r.box[2]))
+	header := func(row []*Node) bool {
+		return len(row) > 0 && row[0].Header
+	}
+	for i, row := range rows {
+		if i > 0 && header(rows[i-1]) && !header(row) {
+			lines = append(lines, border(r.box[3], r.box[4], r.box[5]))
+		}
+		var cellLines [][]string
+		var cellWidths []int
+		height := 1
+		col := 0
+		for j, cell := range row {
+			w := 3 * (cellSpan(cell) - 1)
+			for c := col; c < col+cellSpan(cell) && c < columns; c++ {
+				w += widths[c]
+			}
+			l := wrap(units[i][j], w)
+			if len(l) > height {
+				height = len(l)
+			}
+			cellLines = append(cellLines, l)
+			cellWidths = append(cellWidths, w)
+			col += cellSpan(cell)
+		}
+		for ; col < columns; col++ {
+			cellLines = append(cellLines, nil)
+			cellWidths = append(cellWidths, widths[col])
+		}
+		for k := 0; k < height; k++ {
+			line := vertical
+			for j, w := range cellWidths {
+				text := ""
+				if k < len(cellLines[j]) {
+					text = cellLines[j][k]
+				}
+				align := AlignDefault
+				if j < len(row) {
+					align = row[j].Align
+				}
+				line += " " + padText(text, w, align) + " " + vertical
+			}
+			lines = append(lines, line)
+		}
+	}
+	return append(lines, border(r.box[6], r.box[7], r.box[8]))
+}
+
+// padText pads text with spaces to the width.
+func padText(text string, width int, align int) string {
+	pad := width - textWidth(text)
+	if pad <= 0 {
+		return text
+	}
+	switch align {
+	case AlignRight:
+		return strings.Repeat(" ", pad) + text
+	case AlignCenter:
+		return strings.Repeat(" ", pad/2) + text + strings.Repeat(" ", pad-pad/2)
+	}
+	return text + strings.Repeat(" ", pad)
+}
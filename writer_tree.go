@@ -0,0 +1,368 @@
+package markdown
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Node types
+const (
+	NodeDocument    = "document"
+	NodeText        = "text"
+	NodeStyle       = "style"
+	NodeHeading     = "heading"
+	NodeParagraph   = "paragraph"
+	NodeLink        = "link"
+	NodeImage       = "image"
+	NodeHr          = "hr"
+	NodeLineBreak   = "linebreak"
+	NodeStrike      = "strike"
+	NodeEmphasis    = "emphasis"
+	NodeStrong      = "strong"
+	NodeCode        = "code"
+	NodeMark        = "mark"
+	NodeInsert      = "insert"
+	NodeSuperscript = "superscript"
+	NodeSubscript   = "subscript"
+	NodeList        = "list"
+	NodeListItem    = "listitem"
+	NodeTable       = "table"
+	NodeTableRow    = "tablerow"
+	NodeCaption     = "caption"
+	NodeTableHead   = "thead"
+	NodeTableBody   = "tbody"
+	NodeTableCell   = "tablecell"
+	NodeCheckBox    = "checkbox"
+	NodeRuby        = "ruby"
+	NodeAbbr        = "abbr"
+	NodeQuote       = "quote"
+	NodeCodeBlock   = "codeblock"
+)
+
+// Node is an element of a document tree built by TreeWriter.
+type Node struct {
+	Type     string  `json:"type"`
+	Text     string  `json:"text,omitempty"`    // text, style, heading, abbr and ruby base
+	Level    int     `json:"level,omitempty"`   // heading level
+	URL      string  `json:"url,omitempty"`     // link and image
+	Title    string  `json:"title,omitempty"`   // link, image, abbr and code block
+	Alt      string  `json:"alt,omitempty"`     // image
+	Options  int     `json:"options,omitempty"` // link and image
+	Mode     int     `json:"mode,omitempty"`    // list: 0 bullet, 1 ordered
+	Checked  bool    `json:"checked,omitempty"` // checkbox
+	Align    int     `json:"align,omitempty"`   // table cell
+	Span     int     `json:"span,omitempty"`    // table cell
+	Header   bool    `json:"header,omitempty"`  // table cell
+	Lang     string  `json:"lang,omitempty"`    // code block
+	Class    string  `json:"class,omitempty"`   // style
+	Color    string  `json:"color,omitempty"`   // style
+	Flags    int     `json:"flags,omitempty"`   // style
+	Hard     bool    `json:"hard,omitempty"`    // linebreak
+	Reading  string  `json:"reading,omitempty"` // ruby
+	Attrs    Attrs   `json:"attrs,omitempty"`
+	Children []*Node `json:"children,omitempty"`
+}
+
+// TextContent returns the concatenated text in the node.
+func (n *Node) TextContent() string {
+	switch n.Type {
+	case NodeText, NodeStyle, NodeHeading, NodeAbbr, NodeRuby:
+		return n.Text
+	case NodeImage:
+		return n.Alt
+	}
+	s := ""
+	for _, c := range n.Children {
+		s += c.TextContent()
+	}
+	return s
+}
+
+// tableRows returns the caption and the cells of each row in a table node.
+func tableRows(table *Node) (caption *Node, rows [][]*Node) {
+	var collect func(n *Node)
+	collect = func(n *Node) {
+		for _, c := range n.Children {
+			switch c.Type {
+			case NodeCaption:
+				caption = c
+			case NodeTableRow:
+				var cells []*Node
+				for _, cell := range c.Children {
+					if cell.Type == NodeTableCell {
+						cells = append(cells, cell)
+					}
+				}
+				rows = append(rows, cells)
+			default:
+				collect(c)
+			}
+		}
+	}
+	collect(table)
+	return caption, rows
+}
+
+// TreeWriter : impl for DocWriter. It builds a document tree.
+type TreeWriter struct {
+	Root  *Node
+	stack []*Node
+	attrs Attrs
+}
+
+func NewTreeWriter() *TreeWriter {
+	root := &Node{Type: NodeDocument}
+	return &TreeWriter{Root: root, stack: []*Node{root}}
+}
+
+func (w *TreeWriter) add(n *Node) {
+	if n.Type != NodeText && n.Type != NodeStyle {
+		n.Attrs = w.attrs
+		w.attrs = nil
+	}
+	parent := w.stack[len(w.stack)-1]
+	parent.Children = append(parent.Children, n)
+}
+
+func (w *TreeWriter) open(n *Node) int {
+	w.add(n)
+	w.stack = append(w.stack, n)
+	return len(w.stack) - 1
+}
+
+func (w *TreeWriter) leaf(n *Node) int {
+	w.add(n)
+	return DUMMY_DEPTH
+}
+
+func (w *TreeWriter) Heading(text string, level int) int {
+	return w.leaf(&Node{Type: NodeHeading, Text: text, Level: level})
+}
+
+func (w *TreeWriter) Paragraph() int {
+	return w.open(&Node{Type: NodeParagraph})
+}
+
+func (w *TreeWriter) Link(url string, title string, opt int) int {
+	return w.open(&Node{Type: NodeLink, URL: url, Title: title, Options: opt})
+}
+
+func (w *TreeWriter) Image(url string, title, alt string, opt int) int {
+	return w.leaf(&Node{Type: NodeImage, URL: url, Title: title, Alt: alt, Options: opt})
+}
+
+func (w *TreeWriter) Hr() int {
+	return w.leaf(&Node{Type: NodeHr})
+}
+
+func (w *TreeWriter) LineBreak(hard bool) int {
+	return w.leaf(&Node{Type: NodeLineBreak, Hard: hard})
+}
+
+func (w *TreeWriter) List(mode int) int {
+	return w.open(&Node{Type: NodeList, Mode: mode})
+}
+
+func (w *TreeWriter) ListItem() int {
+	return w.open(&Node{Type: NodeListItem})
+}
+
+func (w *TreeWriter) Table() int {
+	return w.open(&Node{Type: NodeTable})
+}
+
+func (w *TreeWriter) TableRow() int {
+	return w.open(&Node{Type: NodeTableRow})
+}
+
+func (w *TreeWriter) TableCaption() int {
+	return w.open(&Node{Type: NodeCaption})
+}
+
+func (w *TreeWriter) TableHead() int {
+	return w.open(&Node{Type: NodeTableHead})
+}
+
+func (w *TreeWriter) TableBody() int {
+	return w.open(&Node{Type: NodeTableBody})
+}
+
+func (w *TreeWriter) TableCell(opt TableCellOptions) int {
+	return w.open(&Node{Type: NodeTableCell, Align: opt.Align, Span: opt.Span, Header: opt.Header})
+}
+
+func (w *TreeWriter) CheckBox(checked bool) int {
+	return w.leaf(&Node{Type: NodeCheckBox, Checked: checked})
+}
+
+func (w *TreeWriter) Ruby(base string, reading string) int {
+	return w.leaf(&Node{Type: NodeRuby, Text: base, Reading: reading})
+}
+
+func (w *TreeWriter) Abbr(text string, title string) int {
+	return w.leaf(&Node{Type: NodeAbbr, Text: text, Title: title})
+}
+
+func (w *TreeWriter) Strike() int {
+	return w.open(&Node{Type: NodeStrike})
+}
+
+func (w *TreeWriter) Emphasis() int {
+	return w.open(&Node{Type: NodeEmphasis})
+}
+
+func (w *TreeWriter) Strong() int {
+	return w.open(&Node{Type: NodeStrong})
+}
+
+func (w *TreeWriter) Code() int {
+	return w.open(&Node{Type: NodeCode})
+}
+
+func (w *TreeWriter) Mark() int {
+	return w.open(&Node{Type: NodeMark})
+}
+
+func (w *TreeWriter) Insert() int {
+	return w.open(&Node{Type: NodeInsert})
+}
+
+func (w *TreeWriter) Superscript() int {
+	return w.open(&Node{Type: NodeSuperscript})
+}
+
+func (w *TreeWriter) Subscript() int {
+	return w.open(&Node{Type: NodeSubscript})
+}
+
+func (w *TreeWriter) QuoteBlock() int {
+	return w.open(&Node{Type: NodeQuote})
+}
+
+func (w *TreeWriter) CodeBlock(lang string, title string) int {
+	return w.open(&Node{Type: NodeCodeBlock, Lang: lang, Title: title})
+}
+
+func (w *TreeWriter) WriteStyle(text string, className string, color string, flags int) {
+	w.add(&Node{Type: NodeStyle, Text: text, Class: className, Color: color, Flags: flags})
+}
+
+// blockContainers are node types whose children are blocks, rows or items.
+// Whitespace written directly into them is not part of the content.
+var blockContainers = map[string]bool{
+	NodeDocument: true, NodeList: true, NodeTable: true, NodeTableHead: true, NodeTableBody: true, NodeTableRow: true,
+}
+
+func (w *TreeWriter) Write(text string) {
+	parent := w.stack[len(w.stack)-1]
+	if text == "" || blockContainers[parent.Type] && strings.TrimSpace(text) == "" {
+		return
+	}
+	if l := len(parent.Children); l > 0 && parent.Children[l-1].Type == NodeText {
+		parent.Children[l-1].Text += text
+		return
+	}
+	w.add(&Node{Type: NodeText, Text: text})
+}
+
+func (w *TreeWriter) Attributes(attrs Attrs) {
+	w.attrs = attrs
+}
+
+func (w *TreeWriter) End(lv int) {
+	if lv < 1 {
+		lv = 1
+	}
+	if len(w.stack) > lv {
+		w.stack = w.stack[:lv]
+	}
+}
+
+func (w *TreeWriter) Close() {
+	w.End(0)
+}
+
+// Replay writes the node and its descendants to the writer.
+// It makes the same calls as the parser which built the tree, including the
+// line breaks written before blocks other than paragraphs at the document level.
+func Replay(n *Node, writer DocWriter) error {
+	if len(n.Attrs) > 0 {
+		writer.Attributes(n.Attrs)
+	}
+	lv := -1
+	switch n.Type {
+	case NodeDocument:
+	case NodeText:
+		writer.Write(n.Text)
+	case NodeStyle:
+		writer.WriteStyle(n.Text, n.Class, n.Color, n.Flags)
+	case NodeHeading:
+		writer.Heading(n.Text, n.Level)
+	case NodeHr:
+		writer.Hr()
+	case NodeLineBreak:
+		writer.LineBreak(n.Hard)
+	case NodeCheckBox:
+		writer.CheckBox(n.Checked)
+	case NodeRuby:
+		writer.Ruby(n.Text, n.Reading)
+	case NodeAbbr:
+		writer.Abbr(n.Text, n.Title)
+	case NodeImage:
+		lv = writer.Image(n.URL, n.Title, n.Alt, n.Options)
+	case NodeParagraph:
+		lv = writer.Paragraph()
+	case NodeLink:
+		lv = writer.Link(n.URL, n.Title, n.Options)
+	case NodeStrike:
+		lv = writer.Strike()
+	case NodeEmphasis:
+		lv = writer.Emphasis()
+	case NodeStrong:
+		lv = writer.Strong()
+	case NodeCode:
+		lv = writer.Code()
+	case NodeMark:
+		lv = writer.Mark()
+	case NodeInsert:
+		lv = writer.Insert()
+	case NodeSuperscript:
+		lv = writer.Superscript()
+	case NodeSubscript:
+		lv = writer.Subscript()
+	case NodeList:
+		lv = writer.List(n.Mode)
+	case NodeListItem:
+		lv = writer.ListItem()
+	case NodeTable:
+		lv = writer.Table()
+	case NodeTableRow:
+		lv = writer.TableRow()
+	case NodeCaption:
+		lv = writer.TableCaption()
+	case NodeTableHead:
+		lv = writer.TableHead()
+	case NodeTableBody:
+		lv = writer.TableBody()
+	case NodeTableCell:
+		lv = writer.TableCell(TableCellOptions{Align: n.Align, Span: n.Span, Header: n.Header})
+	case NodeQuote:
+		lv = writer.QuoteBlock()
+	case NodeCodeBlock:
+		lv = writer.CodeBlock(n.Lang, n.Title)
+	default:
+		return fmt.Errorf("unknown node type: %q", n.Type)
+	}
+	for _, c := range n.Children {
+		if n.Type == NodeDocument && c.Type != NodeParagraph && c.Type != NodeText && c.Type != NodeStyle {
+			writer.Write("\n")
+		}
+		if err := Replay(c, writer); err != nil {
+			return err
+		}
+	}
+	if lv >= 0 {
+		writer.End(lv)
+	}
+	return nil
+}
@@ -0,0 +1,402 @@
+package markdown
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// tableCellContent renders a table cell. Hard line breaks are replaced by br.
+func tableCellContent(d *markupDialect, cell *Node, br string) string {
+	s := ""
+	for _, c := range cell.Children {
+		if c.Type == NodeLineBreak {
+			s += br
+		} else {
+			s += d.inline(c)
+		}
+	}
+	return s
+}
+
+var mediaWikiEscaper = strings.NewReplacer(
+	"&", "&amp;", "<", "&lt;", ">", "&gt;", "[", "&#91;", "]", "&#93;",
+	"{", "&#123;", "}", "&#125;", "|", "&#124;", "''", "&#39;'", "~~~", "&#126;~~", "__", "&#95;_",
+)
+
+var mediaWikiLineStartRe = regexp.MustCompile(`^[*#:;= ]|^----`)
+
+var mediaWikiDialect = &markupDialect{
+	escape: mediaWikiEscaper.Replace,
+	lineStart: func(line string) string {
+		if mediaWikiLineStartRe.MatchString(line) {
+			return "<nowiki/>" + line
+		}
+		return line
+	},
+	wraps: map[string][2]string{
+		NodeStrong:      {"'''", "'''"},
+		NodeEmphasis:    {"''", "''"},
+		NodeStrike:      {"<s>", "</s>"},
+		NodeInsert:      {"<ins>", "</ins>"},
+		NodeMark:        {"<mark>", "</mark>"},
+		NodeSuperscript: {"<sup>", "</sup>"},
+		NodeSubscript:   {"<sub>", "</sub>"},
+	},
+	code: func(text string) string {
+		return "<code>" + mediaWikiEscaper.Replace(text) + "</code>"
+	},
+	heading: func(text string, level int) string {
+		mark := strings.Repeat("=", level)
+		return mark + " " + text + " " + mark
+	},
+	link: func(url, text string, auto bool) string {
+		if auto {
+			return url
+		}
+		return "[" + strings.Replace(url, " ", "%20", -1) + " " + text + "]"
+	},
+	image: func(url, alt string) string {
+		if alt == "" {
+			return "[[File:" + url + "]]"
+		}
+		return "[[File:" + url + "|" + mediaWikiEscaper.Replace(alt) + "]]"
+	},
+	codeBlock: func(lang, title, code string) string {
+		if lang == "" {
+			return "<pre>" + mediaWikiEscaper.Replace(code) + "</pre>"
+		}
+		return "<syntaxhighlight lang=\"" + lang + "\">\n" + code + "</syntaxhighlight>"
+	},
+	quote: func(lines []string) string {
+		return "<blockquote>" + strings.Join(lines, "\n") + "</blockquote>"
+	},
+	table: func(d *markupDialect, n *Node) string {
+		caption, rows := tableRows(n)
+		s := "{| class=\"wikitable\"\n"
+		if caption != nil {
+			s += "|+ " + d.inlines(caption.Children) + "\n"
+		}
+		for _, row := range rows {
+			s += "|-\n"
+			var cells []string
+			sep := "|"
+			for _, cell := range row {
+				var attrs []string
+				if cellSpan(cell) > 1 {
+					attrs = append(attrs, fmt.Sprintf("colspan=\"%d\"", cellSpan(cell)))
+				}
+				if a := []string{"", "left", "right", "center"}[cell.Align&3]; a != "" {
+					attrs = append(attrs, "style=\"text-align:"+a+"\"")
+				}
+				content := tableCellContent(d, cell, "<br />")
+				if len(attrs) > 0 {
+					content = strings.Join(attrs, " ") + " | " + content
+				}
+				if cell.Header {
+					sep = "!"
+				}
+				cells = append(cells, content)
+			}
+			s += sep + " " + strings.Join(cells, " "+sep+sep+" ") + "\n"
+		}
+		return s + "|}"
+	},
+	hr:        "----",
+	hardBreak: "<br />\n",
+	markers:   [2]string{"*", "#"},
+	chain:     true,
+}
+
+// MediaWikiWriter : impl for DocWriter. It writes MediaWiki markup on Close.
+type MediaWikiWriter struct {
+	*TreeWriter
+	writer io.Writer
+}
+
+func NewMediaWikiWriter(writer io.Writer) *MediaWikiWriter {
+	return &MediaWikiWriter{TreeWriter: NewTreeWriter(), writer: writer}
+}
+
+func (w *MediaWikiWriter) Close() {
+	w.TreeWriter.Close()
+	io.WriteString(w.writer, mediaWikiDialect.render(w.Root))
+	w.TreeWriter = NewTreeWriter()
+}
+
+// jiraEscaper escapes markup characters with backslashes. A backslash itself is written
+// as an entity because "\\\\" is a line break.
+var jiraEscaper = strings.NewReplacer(
+	"\\", "&#92;", "*", "\\*", "_", "\\_", "-", "\\-", "+", "\\+", "^", "\\^", "~", "\\~",
+	"{", "\\{", "}", "\\}", "[", "\\[", "]", "\\]", "|", "\\|", "!", "\\!",
+)
+
+var jiraLineStartRe = regexp.MustCompile(`^(#|h[1-6]\.|bq\.)`)
+
+var jiraDialect = &markupDialect{
+	escape: jiraEscaper.Replace,
+	lineStart: func(line string) string {
+		if jiraLineStartRe.MatchString(line) {
+			return "\\" + line
+		}
+		return line
+	},
+	wraps: map[string][2]string{
+		NodeStrong:      {"*", "*"},
+		NodeEmphasis:    {"_", "_"},
+		NodeStrike:      {"-", "-"},
+		NodeInsert:      {"+", "+"},
+		NodeSuperscript: {"^", "^"},
+		NodeSubscript:   {"~", "~"},
+	},
+	code: func(text string) string {
+		return "{{" + jiraEscaper.Replace(text) + "}}"
+	},
+	heading: func(text string, level int) string {
+		return fmt.Sprintf("h%d. %s", level, text)
+	},
+	link: func(url, text string, auto bool) string {
+		if auto {
+			return "[" + url + "]"
+		}
+		return "[" + text + "|" + url + "]"
+	},
+	image: func(url, alt string) string {
+		if alt == "" {
+			return "!" + url + "!"
+		}
+		return "!" + url + "|alt=" + strings.NewReplacer(",", " ", "|", " ", "!", " ").Replace(alt) + "!"
+	},
+	codeBlock: func(lang, title, code string) string {
+		var params []string
+		if lang != "" {
+			params = append(params, "language="+lang)
+		}
+		if title != "" {
+			params = append(params, "title="+title)
+		}
+		open := "{code}"
+		if len(params) > 0 {
+			open = "{code:" + strings.Join(params, "|") + "}"
+		}
+		return open + "\n" + code + "{code}"
+	},
+	quote: func(lines []string) string {
+		return "{quote}\n" + strings.Join(lines, "\n") + "\n{quote}"
+	},
+	table: func(d *markupDialect, n *Node) string {
+		caption, rows := tableRows(n)
+		var lines []string
+		if caption != nil {
+			lines = append(lines, "*"+d.inlines(caption.Children)+"*")
+		}
+		for _, row := range rows {
+			line := ""
+			for _, cell := range row {
+				sep := "|"
+				if cell.Header {
+					sep = "||"
+				}
+				content := tableCellContent(d, cell, "\\\\")
+				if content == "" {
+					content = " "
+				}
+				line += sep + content + strings.Repeat(sep+" ", cellSpan(cell)-1)
+			}
+			if len(row) > 0 && row[len(row)-1].Header {
+				line += "||"
+			} else {
+				line += "|"
+			}
+			lines = append(lines, line)
+		}
+		return strings.Join(lines, "\n")
+	},
+	hr:        "----",
+	hardBreak: "\\\\\n",
+	markers:   [2]string{"*", "#"},
+	chain:     true,
+}
+
+// JiraWriter : impl for DocWriter. It writes Jira and Confluence wiki markup on Close.
+type JiraWriter struct {
+	*TreeWriter
+	writer io.Writer
+}
+
+func NewJiraWriter(writer io.Writer) *JiraWriter {
+	return &JiraWriter{TreeWriter: NewTreeWriter(), writer: writer}
+}
+
+func (w *JiraWriter) Close() {
+	w.TreeWriter.Close()
+	io.WriteString(w.writer, jiraDialect.render(w.Root))
+	w.TreeWriter = NewTreeWriter()
+}
+
+// asciiDocRefs are attribute references which write markup characters literally.
+var asciiDocRefs = map[rune]string{
+	'*': "{asterisk}", '_': "{underscore}", '`': "{backtick}", '+': "{plus}", '#': "pass:[#]",
+	'^': "{caret}", '~': "{tilde}", '\\': "{backslash}",
+}
+
+var asciiDocScriptRe = regexp.MustCompile(`\^[^\s^]+\^|~[^\s~]+~`)
+var asciiDocMacroRe = regexp.MustCompile(`\[\[|<<|\{[\w-]+\}`)
+
+// escapeAsciiDoc escapes characters only where Asciidoctor would parse them as markup,
+// so that text such as snake_case, a+b and C:\path is written as is.
+func escapeAsciiDoc(text string) string {
+	scripts := map[int]bool{} // byte offsets of ^ and ~ which open superscript or subscript
+	for _, m := range asciiDocScriptRe.FindAllStringIndex(text, -1) {
+		scripts[m[0]] = true
+	}
+	macros := map[int]int{} // byte offsets of anchors, cross references and attribute references
+	for _, m := range asciiDocMacroRe.FindAllStringIndex(text, -1) {
+		macros[m[0]] = m[1]
+	}
+	s := ""
+	for pos := 0; pos < len(text); {
+		if end, ok := macros[pos]; ok {
+			if text[pos] == '{' {
+				s += "\\" + text[pos:end]
+			} else {
+				s += "pass:c[" + text[pos:end] + "]"
+			}
+			pos = end
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(text[pos:])
+		prev, next := ' ', ' '
+		if pos > 0 {
+			prev, _ = utf8.DecodeLastRuneInString(text[:pos])
+		}
+		if pos+size < len(text) {
+			next, _ = utf8.DecodeRuneInString(text[pos+size:])
+		}
+		escape := false
+		switch r {
+		case '*', '_', '`', '+', '#':
+			// unconstrained pairs, or constrained markup at a word boundary.
+			escape = prev == r || next == r ||
+				!isWordRune(prev) && !unicode.IsSpace(next) || !unicode.IsSpace(prev) && !isWordRune(next)
+		case '^', '~':
+			escape = scripts[pos]
+		case '\\':
+			_, escape = asciiDocRefs[next]
+			escape = escape || strings.ContainsRune("[{<", next)
+		}
+		if escape {
+			s += asciiDocRefs[r]
+		} else {
+			s += string(r)
+		}
+		pos += size
+	}
+	return s
+}
+
+var asciiDocLineStartRe = regexp.MustCompile(`^(=|\.|-|'''|\d+\.|\|===)`)
+
+var asciiDocDialect = &markupDialect{
+	escape: escapeAsciiDoc,
+	lineStart: func(line string) string {
+		if asciiDocLineStartRe.MatchString(line) {
+			return "{empty}" + line
+		}
+		return line
+	},
+	wraps: map[string][2]string{
+		NodeStrong:      {"*", "*"},
+		NodeEmphasis:    {"_", "_"},
+		NodeStrike:      {"[line-through]#", "#"},
+		NodeInsert:      {"[underline]#", "#"},
+		NodeMark:        {"#", "#"},
+		NodeSuperscript: {"^", "^"},
+		NodeSubscript:   {"~", "~"},
+	},
+	code: func(text string) string {
+		return "`+" + text + "+`"
+	},
+	heading: func(text string, level int) string {
+		return strings.Repeat("=", level+1) + " " + text
+	},
+	link: func(url, text string, auto bool) string {
+		if auto {
+			return url
+		}
+		return "link:++" + url + "++[" + strings.Replace(text, "]", "\\]", -1) + "]"
+	},
+	image: func(url, alt string) string {
+		return "image:++" + url + "++[" + strings.NewReplacer("]", "\\]", ",", " ").Replace(alt) + "]"
+	},
+	codeBlock: func(lang, title, code string) string {
+		s := ""
+		if title != "" {
+			s += "." + title + "\n"
+		}
+		if lang != "" {
+			s += "[source," + lang + "]\n"
+		}
+		return s + "----\n" + code + "----"
+	},
+	quote: func(lines []string) string {
+		return "____\n" + strings.Join(lines, "\n") + "\n____"
+	},
+	table: func(d *markupDialect, n *Node) string {
+		caption, rows := tableRows(n)
+		if len(rows) == 0 {
+			return ""
+		}
+		var lines, cols []string
+		for _, cell := range rows[0] {
+			for i := 0; i < cellSpan(cell); i++ {
+				cols = append(cols, []string{"<", "<", ">", "^"}[cell.Align&3])
+			}
+		}
+		attrs := "[cols=\"" + strings.Join(cols, ",") + "\""
+		if len(rows[0]) > 0 && rows[0][0].Header {
+			attrs += ",options=\"header\""
+		}
+		lines = append(lines, attrs+"]")
+		if caption != nil {
+			lines = append(lines, "."+d.inlines(caption.Children))
+		}
+		lines = append(lines, "|===")
+		for _, row := range rows {
+			line := ""
+			for _, cell := range row {
+				if line != "" {
+					line += " "
+				}
+				if cellSpan(cell) > 1 {
+					line += fmt.Sprint(cellSpan(cell), "+")
+				}
+				line += "|" + strings.Replace(tableCellContent(d, cell, " +\n"), "|", "\\|", -1)
+			}
+			lines = append(lines, line)
+		}
+		return strings.Join(append(lines, "|==="), "\n")
+	},
+	hr:        "'''",
+	hardBreak: " +\n",
+	markers:   [2]string{"*", "."},
+}
+
+// AsciiDocWriter : impl for DocWriter. It writes AsciiDoc on Close.
+type AsciiDocWriter struct {
+	*TreeWriter
+	writer io.Writer
+}
+
+func NewAsciiDocWriter(writer io.Writer) *AsciiDocWriter {
+	return &AsciiDocWriter{TreeWriter: NewTreeWriter(), writer: writer}
+}
+
+func (w *AsciiDocWriter) Close() {
+	w.TreeWriter.Close()
+	io.WriteString(w.writer, asciiDocDialect.render(w.Root))
+	w.TreeWriter = NewTreeWriter()
+}